@@ -0,0 +1,54 @@
+package crawl
+
+import (
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+// Long message
+var crawlMsg = "Crawl a wiki by following internal links breadth first from a start page, scraping and exporting every page reached exactly as \"wikiscrape get pages\" would. Each page is visited once by its canonical title, so redirects are followed rather than scraped twice.\n\nThe crawl is limited by link depth and a page budget, and can be restricted to titles matching --include patterns, titles not matching --exclude patterns, and links into specific namespaces.\nUsage: wikiscrape crawl <URL> -d 2\n       wikiscrape crawl <page> -w <wiki> -d 2 --exclude \"^List of\""
+
+// Flag vars
+var (
+	wikiName   string
+	depth      int
+	maxPages   int
+	include    []string
+	exclude    []string
+	namespaces []int
+)
+
+// Command
+var CrawlCmd = &cobra.Command{
+	Use:          "crawl <url|page -w wiki>",
+	Short:        "Scrape pages reachable by links from a start page",
+	Long:         crawlMsg,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		opts, err := wiki.NewCrawlOptions(depth, maxPages, include, exclude, namespaces)
+		if err != nil {
+			return err
+		}
+		queryData, err := util.GetQueryData(args[0], wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		return wiki.Crawl(w, queryData.Page, opts, nil)
+	},
+}
+
+func init() {
+	flagSet := CrawlCmd.Flags()
+	flagSet.StringVarP(&wikiName, "wiki", "w", "", "name of the wiki the start page belongs to")
+	flagSet.IntVarP(&depth, "depth", "d", 1, "number of link hops to follow from the start page")
+	flagSet.IntVarP(&maxPages, "max-pages", "m", 50, "maximum number of pages to export, 0 for no limit")
+	flagSet.StringArrayVar(&include, "include", nil, "case-insensitive regex a title must match to be followed (repeatable)")
+	flagSet.StringArrayVar(&exclude, "exclude", nil, "case-insensitive regex of titles not to follow (repeatable)")
+	flagSet.IntSliceVarP(&namespaces, "namespace", "n", []int{0}, "namespace numbers of links to follow (repeatable)")
+}
@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/diff"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Long message
+var diffMsg = "Compare two versions of a page section by section. Both versions are scraped and split into sections as the get command would, then the sections added, removed and changed between them are listed, with the lines of text which differ within each changed section.\n\nEach version is given as a revision id or a time, a date (2024-01-31, UTC) or RFC 3339 timestamp, which selects the revision current at that time. Without --to the current version of the page is compared.\n\nProvide either a URL to a page on a supported wiki, or a page name together with the --wiki flag. Only MediaWiki based wikis keep past versions of pages.\nUsage: wikiscrape diff <URL> --from <rev|time> [--to <rev|time>]\n       wikiscrape diff <page> -w <wiki> --from <rev|time> [--to <rev|time>]"
+
+// Flag vars
+var (
+	wikiName string
+	from     string
+	to       string
+	format   string
+)
+
+// Command
+var DiffCmd = &cobra.Command{
+	Use:          "diff <url|page -w wiki> --from <rev|time>",
+	Short:        "Compare two revisions of a page",
+	Long:         diffMsg,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		queryData, err := util.GetQueryData(args[0], wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		resolver, ok := w.GetScraper().(scrape.RevisionResolver)
+		if !ok {
+			return &util.WikiNotSupportedError{
+				Code: "revisionsnotsupported",
+				Info: fmt.Sprintf("The %s backend does not support getting past revisions", queryData.Info.Backend),
+			}
+		}
+		fromPage, err := getVersion(w, resolver, queryData.Page, from)
+		if err != nil {
+			return err
+		}
+		toPage, err := getVersion(w, resolver, queryData.Page, to)
+		if err != nil {
+			return err
+		}
+		result := diff.Pages(fromPage, toPage)
+		switch util.TrimLower(format) {
+		case "json":
+			return printJSON(result)
+		case "text":
+			printText(result)
+			return nil
+		}
+		return fmt.Errorf("unknown output format %q, expected text or json", format)
+	},
+}
+
+func init() {
+	DiffCmd.Flags().StringVarP(&wikiName, "wiki", "w", "", "name of the wiki the page belongs to")
+	DiffCmd.Flags().StringVar(&from, "from", "", "revision id or time of the older version")
+	DiffCmd.Flags().StringVar(&to, "to", "", "revision id or time of the newer version (default current version)")
+	DiffCmd.Flags().StringVar(&format, "format", "text", "output format: text or json")
+	DiffCmd.MarkFlagRequired("from")
+}
+
+// getVersion scrapes the version of the page specified by path selected by
+// version: a revision id, a time, or the current version when empty. The
+// current version is looked up as the revision current now, so that a
+// cached copy of the page is never compared in its place.
+func getVersion(w wiki.Wiki, resolver scrape.RevisionResolver, path string, version string) (*scrape.Page, error) {
+	id, err := strconv.Atoi(version)
+	if err != nil {
+		at := time.Now()
+		if version != "" {
+			if at, err = util.ParseTimestamp(version); err != nil {
+				return nil, err
+			}
+		}
+		if id, err = resolver.GetRevisionAt(path, at); err != nil {
+			return nil, err
+		}
+	}
+	return w.GetScraper().GetPage(resolver.RevisionPath(id))
+}
+
+// printJSON writes the diff to stdout as indented JSON.
+func printJSON(result *diff.PageDiff) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// printText writes the diff to stdout. Each differing section is marked
+// "+" when added, "-" when removed and "~" when changed, followed by its
+// deleted and inserted lines.
+func printText(result *diff.PageDiff) {
+	fmt.Printf("%s (revision %d -> %d)\n", result.Title, result.FromRevision, result.ToRevision)
+	if len(result.Sections) == 0 {
+		fmt.Println("No differences")
+		return
+	}
+	marks := map[diff.Status]string{diff.Added: "+", diff.Removed: "-", diff.Changed: "~"}
+	for _, s := range result.Sections {
+		fmt.Printf("\n%s %s\n", marks[s.Status], s.Heading)
+		for _, line := range s.Lines {
+			fmt.Printf("  %s %s\n", line.Op, line.Text)
+		}
+	}
+}
@@ -3,16 +3,28 @@ package get
 import (
 	"fmt"
 
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/logging"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
 	"github.com/mal0ner/wikiscrape/internal/util"
 	"github.com/mal0ner/wikiscrape/internal/wiki"
 	"github.com/spf13/cobra"
 )
 
 // Long message
-var getMsg = "Get and export page/s or sections of pages from a wiki. Subcommands are available both for retrieval of a single page given a page name and wiki provider, or a list of pages given a path to a manifest file.\n\nYou can also provide a URL directly to the get command to scrape a whole page directly. No need to name the provider or page name; if the wiki is supported, it will just work!\nUsage: wikiscrape get <URL>.\n\nFor a list of supported wikis and export formats, please see \"wikiscrape list -h\"."
+var getMsg = "Get and export page/s or sections of pages from a wiki. Subcommands are available both for retrieval of a single page given a page name and wiki provider, or a list of pages given a path to a manifest file.\n\nYou can also provide a URL directly to the get command to scrape a whole page directly. No need to name the provider or page name; if the wiki is supported, it will just work!\nUsage: wikiscrape get <URL>.\n\nMediaWiki URLs may name a page by title, revision (oldid) or page id (curid), e.g. \"/w/index.php?title=Bear&oldid=123\" gets that exact revision. A URL fragment (\"/wiki/Bear#Diet\") selects that section when no section flags are given.\n\nSections can be selected by heading (--section, repeatable), by index (--section-index) or by regular expression (--section-regex), and skipped with --exclude, e.g. --exclude \"References|See also|External links\". Wikis using a REST backend also support --summary, which gets only the lead extract, description and thumbnail of a page.\n\nOn MediaWiki based wikis, past versions of pages can be fetched with --revision <id>, or --at <time> for the version current at that time, e.g. --at 2024-01-31. The revision id of each page is recorded in the export.\n\nPages are printed unless --out-dir is given, which writes each page to a JSON file named after its title. A directory of exported pages can be brought up to date with \"wikiscrape sync\".\n\nFor a list of supported wikis and export formats, please see \"wikiscrape list -h\"."
 
 // Flag vars
-var section string
+var (
+	sections       []string
+	sectionIndices []int
+	sectionRegexes []string
+	excludeRegexes []string
+	summary        bool
+	revision       int
+	at             string
+	outDir         string
+)
 
 // Command
 var GetCmd = &cobra.Command{
@@ -23,68 +35,136 @@ var GetCmd = &cobra.Command{
 	Args:         cobra.ExactArgs(1),
 	SilenceUsage: true,
 	RunE: func(_ *cobra.Command, args []string) error {
-		return getPageFromURL(args[0], section)
+		selectExporter()
+		filter, err := newSectionFilter()
+		if err != nil {
+			return err
+		}
+		return getPageFromURL(args[0], filter)
 	},
 }
 
 func init() {
 	GetCmd.AddCommand(pageCmd)
 	GetCmd.AddCommand(pagesCmd)
-	GetCmd.PersistentFlags().StringVarP(&section, "section", "s", "", "section heading you wish to scrape")
+	flagSet := GetCmd.PersistentFlags()
+	flagSet.StringArrayVarP(&sections, "section", "s", nil, "section heading you wish to scrape (repeatable)")
+	flagSet.IntSliceVar(&sectionIndices, "section-index", nil, "index of a section you wish to scrape, the introduction is 0 (repeatable)")
+	flagSet.StringArrayVar(&sectionRegexes, "section-regex", nil, "case-insensitive regex matching headings you wish to scrape (repeatable)")
+	flagSet.StringArrayVar(&excludeRegexes, "exclude", nil, "case-insensitive regex matching headings you wish to skip (repeatable)")
+	flagSet.BoolVar(&summary, "summary", false, "get only the page summary: lead extract, description and thumbnail (REST backends)")
+	flagSet.IntVar(&revision, "revision", 0, "id of the revision of the page to get (MediaWiki backends)")
+	flagSet.StringVar(&at, "at", "", "get pages as they were at this time, a date (2024-01-31, UTC) or RFC 3339 timestamp (MediaWiki backends)")
+	flagSet.StringVarP(&outDir, "out-dir", "o", "", "write each page to a JSON file in this directory, which \"wikiscrape sync\" can update later")
+	GetCmd.MarkFlagsMutuallyExclusive("revision", "at")
+}
+
+// selectExporter replaces export.Default with a DirExporter when the
+// --out-dir flag is set. It must be called before the wiki is created.
+func selectExporter() {
+	if outDir != "" {
+		export.Default = &export.DirExporter{Dir: outDir}
+	}
+}
+
+// revisionResolver returns the wiki's scraper as a scrape.RevisionResolver,
+// for use with the --revision and --at flags.
+func revisionResolver(w wiki.Wiki, backend string) (scrape.RevisionResolver, error) {
+	resolver, ok := w.GetScraper().(scrape.RevisionResolver)
+	if !ok {
+		return nil, &util.WikiNotSupportedError{
+			Code: "revisionsnotsupported",
+			Info: fmt.Sprintf("The %s backend does not support getting past revisions", backend),
+		}
+	}
+	return resolver, nil
 }
 
-// getWikiFromQueryData identifies and returns the appropriate wiki scraper for the wiki provider listed
-// in the generated queryData from a 'get' command request. Returns a WikiNotSupportedError in the case
-// that the provider is not explicitly supported.
-func getWikiFromQueryData(queryData *util.QueryData) (wiki.Wiki, error) {
-	backend := util.TrimLower(queryData.Info.Backend)
-	switch backend {
-	case "mediawiki":
-		mediaWiki := wiki.NewMediaWiki(backend, queryData.Info.APIPath)
-		return mediaWiki, nil
-	}
-	return nil, &util.WikiNotSupportedError{
-		Code: "backendnotsupported",
-		Info: fmt.Sprintf("The detected backend %s is not yet a supported wiki provider", backend),
+// selectRevision returns the path of the revision of a page selected by the
+// --revision or --at flags, or path unchanged when neither is set.
+func selectRevision(w wiki.Wiki, backend string, path string) (string, error) {
+	if revision == 0 && at == "" {
+		return path, nil
+	}
+	resolver, err := revisionResolver(w, backend)
+	if err != nil {
+		return "", err
+	}
+	if revision != 0 {
+		return resolver.RevisionPath(revision), nil
+	}
+	atTime, err := util.ParseTimestamp(at)
+	if err != nil {
+		return "", err
+	}
+	id, err := resolver.GetRevisionAt(path, atTime)
+	if err != nil {
+		return "", err
 	}
+	return resolver.RevisionPath(id), nil
+}
+
+// newSectionFilter builds a scrape.SectionFilter from the persistent section
+// flags shared by the get command and its subcommands.
+func newSectionFilter() (*scrape.SectionFilter, error) {
+	return scrape.NewSectionFilter(sections, sectionIndices, sectionRegexes, excludeRegexes)
 }
 
 // getPageFromURL parses the provided URL to check for explicit support for the wiki's backend provider before
 // initializing the appropriate scraper and retrieving the requested page. Returns an error indicating the success
 // of page retrieval.
-func getPageFromURL(rawURL string, section string) error {
+func getPageFromURL(rawURL string, filter *scrape.SectionFilter) error {
 	queryData, err := util.GetQueryDataFromURL(rawURL)
 	if err != nil {
 		return err
 	}
-	wiki, err := getWikiFromQueryData(queryData)
+	w, err := wiki.FromQueryData(queryData)
 	if err != nil {
 		return err
 	}
-
-	if section != "" {
-		return wiki.ScrapeSection(queryData.Page, section)
+	if summary {
+		return w.ScrapeSummary(queryData.Page)
+	}
+	queryData.Page, err = selectRevision(w, queryData.Info.Backend, queryData.Page)
+	if err != nil {
+		return err
 	}
-	return wiki.ScrapePage(queryData.Page)
+	if filter.IsEmpty() && queryData.Section != "" {
+		// The URL fragment names a section, which may be a subsection
+		// that cannot be selected on its own.
+		err := w.ScrapeSections(queryData.Page, &scrape.SectionFilter{Headings: []string{queryData.Section}})
+		if _, ok := err.(*scrape.SectionNotFoundError); !ok {
+			return err
+		}
+		logging.Log.Warnf("section %q not found, getting the whole page", queryData.Section)
+	}
+	if !filter.IsEmpty() {
+		return w.ScrapeSections(queryData.Page, filter)
+	}
+	return w.ScrapePage(queryData.Page)
 }
 
 // getPageFromName checks wikiscrape support for the provided wikiName. If supported, the appropriate
 // scraper is initialized and the page with the provided name is scraped. Returns an error indicating
 // the success of page retrieval.
-func getPageFromName(pageName string, wikiName string, section string) error {
-	// TODO: Maybe make the section into an array and support it in the command line apps later,
-	// could give users opportunity to ask for multiple sections. would just need to loop over them
-	// in the wiki.GetSection() function.
+func getPageFromName(pageName string, wikiName string, filter *scrape.SectionFilter) error {
 	queryData, err := util.GetQueryDataFromName(pageName, wikiName)
 	if err != nil {
 		return err
 	}
-	wiki, err := getWikiFromQueryData(queryData)
+	w, err := wiki.FromQueryData(queryData)
+	if err != nil {
+		return err
+	}
+	if summary {
+		return w.ScrapeSummary(queryData.Page)
+	}
+	queryData.Page, err = selectRevision(w, queryData.Info.Backend, queryData.Page)
 	if err != nil {
 		return err
 	}
-	if section != "" {
-		return wiki.ScrapeSection(queryData.Page, section)
+	if !filter.IsEmpty() {
+		return w.ScrapeSections(queryData.Page, filter)
 	}
-	return wiki.ScrapePage(queryData.Page)
+	return w.ScrapePage(queryData.Page)
 }
@@ -5,7 +5,7 @@ import (
 )
 
 // Long message
-var pageMsg = "Get and export a single page from a wiki, given the page name and wiki provider.\n\nFor a list of supported wikis and export formats, please see \"wikiscrape list -h\".\n"
+var pageMsg = "Get and export a single page from a wiki, given the page name and wiki provider.\n\nThe wiki can also be a path to a local MediaWiki XML dump (.xml, .xml.gz or .xml.bz2), as produced by Special:Export or published on dumps.wikimedia.org, a Kiwix ZIM archive (.zim), a single file TiddlyWiki (.html), or a local clone of a Git backed wiki such as a GitHub wiki or Gollum repository.\n\nWikis published in several languages, such as Wikipedia, Wiktionary and Fandom wikis, can be read in another language with --lang, e.g. \"wikiscrape get page Bear -w wikipedia --lang de\". Page URLs from any language edition, including mobile URLs, are recognised automatically.\n\nAny Fandom wiki can be named by its host, optionally followed by a language code, e.g. \"-w minecraft.fandom.com\" or \"-w minecraft.fandom.com/de\".\n\nFor a list of supported wikis and export formats, please see \"wikiscrape list -h\".\n"
 
 // Flag vars
 var wikiName string
@@ -14,16 +14,21 @@ var wikiName string
 var pageCmd = &cobra.Command{
 	Use:          "page",
 	Short:        "Get a single page",
-	Long:         "Get a single page",
+	Long:         pageMsg,
 	Args:         cobra.ExactArgs(1),
 	SilenceUsage: true,
 	RunE: func(_ *cobra.Command, args []string) error {
+		selectExporter()
 		pageName := args[0]
-		return getPageFromName(pageName, wikiName, section)
+		filter, err := newSectionFilter()
+		if err != nil {
+			return err
+		}
+		return getPageFromName(pageName, wikiName, filter)
 	},
 }
 
 func init() {
-	pageCmd.Flags().StringVarP(&wikiName, "wiki", "w", "", "name of the wiki you wish to scrape")
+	pageCmd.Flags().StringVarP(&wikiName, "wiki", "w", "", "name of the wiki, or path to a local wiki file such as an XML dump, you wish to scrape")
 	pageCmd.MarkFlagRequired("wiki")
 }
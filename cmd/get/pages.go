@@ -3,15 +3,21 @@ package get
 import (
 	"fmt"
 
+	"github.com/mal0ner/wikiscrape/internal/logging"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
 	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
 	"github.com/spf13/cobra"
 )
 
 // Long message
-var pagesMsg = "Get and export a list of pages whose names are defined in a 'manifest' file. The persistent 'section' flag available to the get command and all its subcommands allows for retrieving specific sections from all pages in the manifest."
+var pagesMsg = "Get and export a list of pages whose names are defined in a 'manifest' file. The persistent section selection flags ('section', 'section-index', 'section-regex' and 'exclude') available to the get command and all its subcommands allow for retrieving specific sections from all pages in the manifest.\n\nLarge manifests can be fetched with --strategy batch, which retrieves the source of up to 50 pages per request instead of one rendered page at a time. Batched pages are parsed from wikitext, so their text may differ slightly from the rendered page.\n\nWith --at, every page is fetched as it was at the given time, for a reproducible snapshot of the manifest."
 
 // Flag vars
-var manFile string
+var (
+	manFile  string
+	strategy string
+)
 
 // Command
 var pagesCmd = &cobra.Command{
@@ -21,6 +27,15 @@ var pagesCmd = &cobra.Command{
 	Args:         cobra.NoArgs,
 	SilenceUsage: true,
 	RunE: func(_ *cobra.Command, _ []string) error {
+		selectExporter()
+		filter, err := newSectionFilter()
+		if err != nil {
+			return err
+		}
+		fetchStrategy, err := wiki.ParseFetchStrategy(strategy)
+		if err != nil {
+			return err
+		}
 		pageNames, err := util.ReadManifestFrom(manFile)
 		if err != nil {
 			return err
@@ -30,19 +45,49 @@ var pagesCmd = &cobra.Command{
 			fmt.Println(err.Error())
 			return err
 		}
-		wiki, err := getWikiFromQueryData(queryData)
+		w, err := wiki.FromQueryData(queryData)
 		if err != nil {
 			return err
 		}
-		err = wiki.ScrapeManifest(pageNames)
-		return nil
+		if revision != 0 {
+			return fmt.Errorf("--revision selects a single page and cannot be used with a manifest, use --at instead")
+		}
+		if at != "" {
+			if fetchStrategy == wiki.FetchBatch {
+				return fmt.Errorf("--at fetches each page by revision and cannot be used with --strategy batch")
+			}
+			if pageNames, err = selectRevisions(w, queryData.Info.Backend, pageNames); err != nil {
+				return err
+			}
+		}
+		return w.ScrapeManifest(pageNames, filter, fetchStrategy)
 	},
 }
 
+// selectRevisions maps each page of a manifest to the path of its revision
+// at the time given by the --at flag. Pages without a revision at that time
+// are skipped.
+func selectRevisions(w wiki.Wiki, backend string, pageNames util.Manifest) (util.Manifest, error) {
+	var revisions util.Manifest
+	for _, path := range pageNames {
+		revisionPath, err := selectRevision(w, backend, path)
+		if _, ok := err.(*scrape.MediaWikiAPIError); ok {
+			logging.Log.Warnf("skipping %s: %v", path, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revisionPath)
+	}
+	return revisions, nil
+}
+
 func init() {
 	flagSet := pagesCmd.Flags()
 	flagSet.StringVarP(&manFile, "from-manifest", "f", "", "path to the manifest file")
-	flagSet.StringVarP(&wikiName, "wiki", "w", "", "name of the wiki you wish to scrape")
+	flagSet.StringVarP(&wikiName, "wiki", "w", "", "name of the wiki, or path to a local wiki file such as an XML dump, you wish to scrape")
+	flagSet.StringVar(&strategy, "strategy", string(wiki.FetchParse), "how pages are fetched: parse (one rendered page per request) or batch (page source, many pages per request)")
 	pagesCmd.MarkPersistentFlagRequired("wiki")
 	pagesCmd.MarkFlagRequired("from-manifest")
 	pagesCmd.MarkFlagRequired("wiki")
@@ -0,0 +1,137 @@
+package history
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Long message
+var historyMsg = "List the revisions of a page, newest first: the id, timestamp, user, edit summary, change in size and tags of every edit. Revision ids can be passed to the --revision flag of the get command, or to the diff command.\n\n--since and --until restrict the listing to edits made in a time range, each a date (2024-01-31, UTC) or RFC 3339 timestamp, and --limit to the newest edits.\n\nProvide either a URL to a page on a supported wiki, or a page name together with the --wiki flag.\nUsage: wikiscrape history <URL>\n       wikiscrape history <page> -w <wiki> --since 2024-01-01 --format csv"
+
+// Flag vars
+var (
+	wikiName string
+	since    string
+	until    string
+	limit    int
+	format   string
+)
+
+// Command
+var HistoryCmd = &cobra.Command{
+	Use:          "history <url|page -w wiki>",
+	Short:        "List the revisions of a page",
+	Long:         historyMsg,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		opts := &scrape.HistoryOptions{Limit: limit}
+		var err error
+		if opts.Since, err = parseTime(since); err != nil {
+			return err
+		}
+		if opts.Until, err = parseTime(until); err != nil {
+			return err
+		}
+		queryData, err := util.GetQueryData(args[0], wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		historyLister, ok := w.GetScraper().(scrape.HistoryLister)
+		if !ok {
+			return &util.WikiNotSupportedError{
+				Code: "historynotsupported",
+				Info: fmt.Sprintf("The %s backend does not support listing page history", queryData.Info.Backend),
+			}
+		}
+		revisions, err := historyLister.GetHistory(queryData.Page, opts)
+		if err != nil {
+			return err
+		}
+		switch util.TrimLower(format) {
+		case "json":
+			return printJSON(revisions)
+		case "csv":
+			return printCSV(revisions)
+		case "table":
+			return printTable(revisions)
+		}
+		return fmt.Errorf("unknown output format %q, expected table, json or csv", format)
+	},
+}
+
+func init() {
+	HistoryCmd.Flags().StringVarP(&wikiName, "wiki", "w", "", "name of the wiki the page belongs to")
+	HistoryCmd.Flags().StringVar(&since, "since", "", "only list edits made at or after this time")
+	HistoryCmd.Flags().StringVar(&until, "until", "", "only list edits made at or before this time")
+	HistoryCmd.Flags().IntVarP(&limit, "limit", "l", 0, "maximum number of revisions to list (default all)")
+	HistoryCmd.Flags().StringVar(&format, "format", "table", "output format: table, json or csv")
+}
+
+// parseTime parses the value of a time flag, which may be unset.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return util.ParseTimestamp(value)
+}
+
+// printJSON writes the revisions to stdout as indented JSON.
+func printJSON(revisions []*scrape.Revision) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(revisions)
+}
+
+// printCSV writes the revisions to stdout as CSV with a header row. Tags
+// are separated by semicolons.
+func printCSV(revisions []*scrape.Revision) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"id", "timestamp", "user", "comment", "size", "sizeDelta", "minor", "tags"})
+	for _, r := range revisions {
+		w.Write([]string{
+			strconv.Itoa(r.ID),
+			r.Timestamp,
+			r.User,
+			r.Comment,
+			strconv.Itoa(r.Size),
+			strconv.Itoa(r.SizeDelta),
+			strconv.FormatBool(r.Minor),
+			strings.Join(r.Tags, ";"),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// printTable writes the revisions to stdout as an aligned table. Minor
+// edits are marked with "m" after their size delta.
+func printTable(revisions []*scrape.Revision) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTIMESTAMP\tUSER\tDELTA\tTAGS\tCOMMENT")
+	for _, r := range revisions {
+		delta := fmt.Sprintf("%+d", r.SizeDelta)
+		if r.Minor {
+			delta += " m"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Timestamp, r.User, delta, strings.Join(r.Tags, ","), r.Comment)
+	}
+	return tw.Flush()
+}
@@ -11,7 +11,10 @@ import (
 var listMsg = "List name and other relevant information about wikis that are supported by wikiscrape"
 
 // Flag vars
-var backendFilter string
+var (
+	backendFilter string
+	listBackends  bool
+)
 
 // Command
 var ListCmd = &cobra.Command{
@@ -20,6 +23,12 @@ var ListCmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 	Long:  listMsg,
 	Run: func(_ *cobra.Command, _ []string) {
+		if listBackends {
+			for _, b := range util.GetSupportedBackends() {
+				fmt.Println(b)
+			}
+			return
+		}
 		wikiStrings := util.GetWikiInfoStrings(backendFilter)
 		for _, w := range wikiStrings {
 			fmt.Println(w)
@@ -29,4 +38,5 @@ var ListCmd = &cobra.Command{
 
 func init() {
 	ListCmd.Flags().StringVarP(&backendFilter, "filter-backend", "b", "", "Name of backend you wish to filter by")
+	ListCmd.Flags().BoolVar(&listBackends, "backends", false, "List the names of supported backends instead of wikis")
 }
@@ -0,0 +1,65 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+// Long message
+var allMsg = "Write a manifest of every page in a wiki. This is supported by wikis which can list all of their pages, such as a TiddlyWiki, where every tiddler except system tiddlers is listed.\n\nUsage: wikiscrape manifest all -w <wiki> [-o manifest.json]"
+
+// Flag vars
+var (
+	allWikiName string
+	allOutFile  string
+)
+
+// Command
+var allCmd = &cobra.Command{
+	Use:          "all -w <wiki>",
+	Short:        "Build a manifest of every page in a wiki",
+	Long:         allMsg,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		queryData, err := util.GetQueryDataFromName("", allWikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		lister, ok := w.GetScraper().(scrape.PageLister)
+		if !ok {
+			return &util.WikiNotSupportedError{
+				Code: "listingnotsupported",
+				Info: fmt.Sprintf("The %s backend does not support listing every page", queryData.Info.Backend),
+			}
+		}
+		titles, err := lister.ListPages()
+		if err != nil {
+			return err
+		}
+		if len(titles) == 0 {
+			return fmt.Errorf("no pages found on %s, no manifest written", allWikiName)
+		}
+		if err := util.WriteManifestTo(allOutFile, titles); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d pages to %s\n", len(titles), allOutFile)
+		return nil
+	},
+}
+
+func init() {
+	flagSet := allCmd.Flags()
+	flagSet.StringVarP(&allWikiName, "wiki", "w", "", "name of the wiki, or path to a local wiki file")
+	flagSet.StringVarP(&allOutFile, "output", "o", "manifest.json", "path of the manifest file to write")
+	allCmd.MarkFlagRequired("wiki")
+	allCmd.MarkFlagFilename("output", "json")
+}
@@ -0,0 +1,72 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+// Long message
+var fromCategoryMsg = "Write a manifest of every page in a category. Subcategories can be walked recursively up to a given depth, and members can be filtered by namespace number (0 for articles, 14 for categories).\n\nUsage: wikiscrape manifest from-category <Category> -w <wiki> [-d depth] [-n namespace] [-o manifest.json]"
+
+// Flag vars
+var (
+	wikiName   string
+	depth      int
+	namespaces []int
+	outFile    string
+)
+
+// Command
+var fromCategoryCmd = &cobra.Command{
+	Use:          "from-category <category> -w <wiki>",
+	Short:        "Build a manifest from the members of a category",
+	Long:         fromCategoryMsg,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		queryData, err := util.GetQueryDataFromName(args[0], wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		lister, ok := w.GetScraper().(scrape.CategoryLister)
+		if !ok {
+			return &util.WikiNotSupportedError{
+				Code: "categorynotsupported",
+				Info: fmt.Sprintf("The %s backend does not support listing category members", queryData.Info.Backend),
+			}
+		}
+		titles, err := lister.GetCategoryMembers(queryData.Page, &scrape.CategoryOptions{
+			Depth:      depth,
+			Namespaces: namespaces,
+		})
+		if err != nil {
+			return err
+		}
+		if len(titles) == 0 {
+			return fmt.Errorf("no pages found in %s, no manifest written", queryData.Page)
+		}
+		if err := util.WriteManifestTo(outFile, titles); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d pages to %s\n", len(titles), outFile)
+		return nil
+	},
+}
+
+func init() {
+	flagSet := fromCategoryCmd.Flags()
+	flagSet.StringVarP(&wikiName, "wiki", "w", "", "name of the wiki the category belongs to")
+	flagSet.IntVarP(&depth, "depth", "d", 0, "levels of subcategories to descend into")
+	flagSet.IntSliceVarP(&namespaces, "namespace", "n", []int{0}, "namespace numbers of pages to include (repeatable)")
+	flagSet.StringVarP(&outFile, "output", "o", "manifest.json", "path of the manifest file to write")
+	fromCategoryCmd.MarkFlagRequired("wiki")
+	fromCategoryCmd.MarkFlagFilename("output", "json")
+}
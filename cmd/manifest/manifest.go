@@ -0,0 +1,21 @@
+package manifest
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Long message
+var manifestMsg = "Build manifest files listing page names for use with \"wikiscrape get pages\".\n\nFor a list of supported wikis, please see \"wikiscrape list -h\"."
+
+// Command
+var ManifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Build manifest files of page names",
+	Long:  manifestMsg,
+	Args:  cobra.NoArgs,
+}
+
+func init() {
+	ManifestCmd.AddCommand(fromCategoryCmd)
+	ManifestCmd.AddCommand(allCmd)
+}
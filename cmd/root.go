@@ -3,9 +3,21 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	cachecmd "github.com/mal0ner/wikiscrape/cmd/cache"
+	"github.com/mal0ner/wikiscrape/cmd/crawl"
+	diffcmd "github.com/mal0ner/wikiscrape/cmd/diff"
 	"github.com/mal0ner/wikiscrape/cmd/get"
+	"github.com/mal0ner/wikiscrape/cmd/history"
 	"github.com/mal0ner/wikiscrape/cmd/list"
+	"github.com/mal0ner/wikiscrape/cmd/manifest"
+	"github.com/mal0ner/wikiscrape/cmd/search"
+	synccmd "github.com/mal0ner/wikiscrape/cmd/sync"
+	"github.com/mal0ner/wikiscrape/cmd/toc"
+	"github.com/mal0ner/wikiscrape/cmd/watch"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+	"github.com/mal0ner/wikiscrape/internal/util"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +26,15 @@ const (
 )
 
 // Flag vars
-var printVersion bool
+var (
+	printVersion bool
+	noCache      bool
+	offline      bool
+	cacheDir     string
+	cacheTTL     time.Duration
+	configPath   string
+	lang         string
+)
 
 // Command
 var rootCmd = &cobra.Command{
@@ -22,6 +42,21 @@ var rootCmd = &cobra.Command{
 	Short: "Scrape and export wiki pages!",
 	Long:  "A tool for scraping wikis running on a number of different backends and then exporting the data to different formats",
 	Args:  cobra.MatchAll(cobra.MaximumNArgs(1)),
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if err := util.LoadConfig(configPath, cmd.Flags().Changed("config")); err != nil {
+			return err
+		}
+		util.Language = lang
+		if noCache {
+			if offline {
+				return fmt.Errorf("--offline serves responses from the cache and cannot be used with --no-cache")
+			}
+			return nil
+		}
+		cache.Default = cache.New(cacheDir, cacheTTL)
+		cache.Default.Offline = offline
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if printVersion {
 			fmt.Println(version)
@@ -33,8 +68,23 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(get.GetCmd)
 	rootCmd.AddCommand(list.ListCmd)
+	rootCmd.AddCommand(toc.TocCmd)
+	rootCmd.AddCommand(search.SearchCmd)
+	rootCmd.AddCommand(manifest.ManifestCmd)
+	rootCmd.AddCommand(crawl.CrawlCmd)
+	rootCmd.AddCommand(cachecmd.CacheCmd)
+	rootCmd.AddCommand(diffcmd.DiffCmd)
+	rootCmd.AddCommand(history.HistoryCmd)
+	rootCmd.AddCommand(synccmd.SyncCmd)
+	rootCmd.AddCommand(watch.WatchCmd)
 
 	rootCmd.Flags().BoolVarP(&printVersion, "version", "v", false, "print version")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", util.DefaultConfigPath(), "config file adding wikis and their API tokens")
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", "", "language edition of wikis named with --wiki, such as de for German Wikipedia")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "do not read or store cached API responses")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", cache.DefaultDir(), "directory storing cached API responses")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "serve every response from the cache and fail on cache misses instead of contacting the wiki")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", time.Hour, "how long cached responses are used before being revalidated")
 }
 
 func Execute() {
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"fmt"
+	"os"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Long message
+var searchMsg = "Search a supported wiki for pages matching a query and print their titles and snippets. Results can be written directly to a manifest file for use with \"wikiscrape get pages\".\n\nUsage: wikiscrape search <query> -w <wiki> [-l limit] [-n namespace] [-m manifest.json]"
+
+// Flag vars
+var (
+	wikiName   string
+	limit      int
+	namespaces []int
+	manFile    string
+	format     string
+)
+
+// Command
+var SearchCmd = &cobra.Command{
+	Use:          "search <query> -w <wiki>",
+	Short:        "Search a wiki for pages",
+	Long:         searchMsg,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		queryData, err := util.GetQueryDataFromName("", wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		searcher, ok := w.GetScraper().(scrape.Searcher)
+		if !ok {
+			return &util.WikiNotSupportedError{
+				Code: "searchnotsupported",
+				Info: fmt.Sprintf("The %s backend does not support searching", queryData.Info.Backend),
+			}
+		}
+		results, err := searcher.Search(args[0], &scrape.SearchOptions{
+			Limit:      limit,
+			Namespaces: namespaces,
+		})
+		if err != nil {
+			return err
+		}
+		if manFile != "" && len(results) == 0 {
+			return fmt.Errorf("no pages found for %q, no manifest written", args[0])
+		}
+		if manFile != "" {
+			man := make(util.Manifest, len(results))
+			for i, r := range results {
+				man[i] = r.Title
+			}
+			if err := util.WriteManifestTo(manFile, man); err != nil {
+				return err
+			}
+		}
+		switch util.TrimLower(format) {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		case "text":
+			for _, r := range results {
+				fmt.Println(r.Title)
+				if r.Snippet != "" {
+					fmt.Println("    " + r.Snippet)
+				}
+			}
+			return nil
+		}
+		return fmt.Errorf("unknown output format %q, expected text or json", format)
+	},
+}
+
+func init() {
+	flagSet := SearchCmd.Flags()
+	flagSet.StringVarP(&wikiName, "wiki", "w", "", "name of the wiki you wish to search")
+	flagSet.IntVarP(&limit, "limit", "l", 10, "maximum number of results")
+	flagSet.IntSliceVarP(&namespaces, "namespace", "n", []int{0}, "namespace numbers to search (repeatable)")
+	flagSet.StringVarP(&manFile, "manifest", "m", "", "write the result titles to this manifest file")
+	flagSet.StringVar(&format, "format", "text", "output format: text or json")
+	SearchCmd.MarkFlagRequired("wiki")
+	SearchCmd.MarkFlagFilename("manifest", "json")
+}
@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"fmt"
+
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+// Long message
+var syncMsg = "Update a directory of pages exported with \"wikiscrape get --out-dir\" to match the wiki. The latest revision id of every exported page is looked up in batches of 50, and only pages edited since they were exported are scraped again, so a large export is kept current with a handful of requests.\n\nMoved pages are scraped under their new title, replacing the old file, and the files of deleted pages are removed. Every change is printed and appended to " + wiki.ChangeLogName + " in the directory.\nUsage: wikiscrape sync <dir> -w <wiki>"
+
+// Flag vars
+var wikiName string
+
+// Command
+var SyncCmd = &cobra.Command{
+	Use:          "sync <dir> -w <wiki>",
+	Short:        "Re-scrape exported pages which changed on the wiki",
+	Long:         syncMsg,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		queryData, err := util.GetQueryDataFromName("", wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		changes, err := wiki.Sync(w, args[0])
+		for _, c := range changes {
+			switch c.Kind {
+			case wiki.PageMoved:
+				fmt.Printf("%s %s -> %s (revision %d)\n", c.Kind, c.Title, c.NewTitle, c.ToRevision)
+			case wiki.PageDeleted:
+				fmt.Printf("%s %s\n", c.Kind, c.Title)
+			default:
+				fmt.Printf("%s %s (revision %d -> %d)\n", c.Kind, c.Title, c.FromRevision, c.ToRevision)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d pages changed\n", len(changes))
+		return nil
+	},
+}
+
+func init() {
+	SyncCmd.Flags().StringVarP(&wikiName, "wiki", "w", "", "name of the wiki the pages were exported from")
+	SyncCmd.MarkFlagRequired("wiki")
+}
@@ -0,0 +1,92 @@
+package toc
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Long message
+var tocMsg = "List the table of contents of a page: the level, number, heading, anchor and byte offset of every section, without scraping the page content. Use it to find exact section names and indices before using the --section flags of the get command.\n\nProvide either a URL to a page on a supported wiki, or a page name together with the --wiki flag.\nUsage: wikiscrape toc <URL>\n       wikiscrape toc <page> -w <wiki>"
+
+// Flag vars
+var (
+	wikiName string
+	format   string
+)
+
+// Command
+var TocCmd = &cobra.Command{
+	Use:          "toc <url|page -w wiki>",
+	Short:        "List the sections of a page",
+	Long:         tocMsg,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		queryData, err := util.GetQueryData(args[0], wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		tocScraper, ok := w.GetScraper().(scrape.TOCScraper)
+		if !ok {
+			return &util.WikiNotSupportedError{
+				Code: "tocnotsupported",
+				Info: fmt.Sprintf("The %s backend does not support listing a table of contents", queryData.Info.Backend),
+			}
+		}
+		toc, err := tocScraper.GetTOC(queryData.Page)
+		if err != nil {
+			return err
+		}
+		switch util.TrimLower(format) {
+		case "json":
+			return printJSON(toc)
+		case "text":
+			return printText(toc)
+		}
+		return fmt.Errorf("unknown output format %q, expected text or json", format)
+	},
+}
+
+func init() {
+	TocCmd.Flags().StringVarP(&wikiName, "wiki", "w", "", "name of the wiki the page belongs to")
+	TocCmd.Flags().StringVar(&format, "format", "text", "output format: text or json")
+}
+
+// printJSON writes the table of contents to stdout as indented JSON.
+func printJSON(toc *scrape.TOC) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(toc)
+}
+
+// printText writes the table of contents to stdout as an aligned table,
+// indenting each heading according to its level.
+func printText(toc *scrape.TOC) error {
+	fmt.Println(toc.Title)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "LEVEL\tNUMBER\tHEADING\tANCHOR\tOFFSET")
+	for _, e := range toc.Entries {
+		offset := "-"
+		if e.ByteOffset != nil {
+			offset = strconv.Itoa(*e.ByteOffset)
+		}
+		indent := strings.Repeat("  ", max(e.Level-1, 0))
+		fmt.Fprintf(tw, "%d\t%s\t%s%s\t%s\t%s\n", e.Level, e.Number, indent, e.Heading, e.Anchor, offset)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+	"github.com/spf13/cobra"
+)
+
+// Long message
+var watchMsg = "Watch a wiki for changes, polling its recent changes at a fixed interval and scraping and exporting every page edited, created or moved since the previous poll. Moved pages are scraped under their new title, and deletions are logged. With --out-dir, the files of deleted pages are removed, and those of moved pages are replaced by the file of the new title.\n\nBy default every page in the main namespace is watched. Use --namespace to watch other namespaces, and --category or --from-manifest to only scrape pages in a category or listed in a manifest file.\n\nThe last change seen is saved to the --state file after every poll, so a restarted watch continues where it left off; without a saved state the watch starts from now. Combine with --out-dir to keep a directory of exported pages current. Stop watching with Ctrl-C.\nUsage: wikiscrape watch -w <wiki> --interval 10m --out-dir pages"
+
+// Flag vars
+var (
+	wikiName   string
+	interval   time.Duration
+	namespaces []int
+	categories []string
+	manFile    string
+	statePath  string
+	outDir     string
+)
+
+// Command
+var WatchCmd = &cobra.Command{
+	Use:          "watch -w <wiki>",
+	Short:        "Re-scrape pages as they change on a wiki",
+	Long:         watchMsg,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid interval %s, expected a positive duration such as 10m", interval)
+		}
+		opts := &wiki.WatchOptions{
+			Interval:   interval,
+			Namespaces: namespaces,
+			Categories: categories,
+			StatePath:  statePath,
+			OutDir:     outDir,
+		}
+		if manFile != "" {
+			titles, err := util.ReadManifestFrom(manFile)
+			if err != nil {
+				return err
+			}
+			opts.Titles = titles
+		}
+		if outDir != "" {
+			export.Default = &export.DirExporter{Dir: outDir}
+		}
+		queryData, err := util.GetQueryDataFromName("", wikiName)
+		if err != nil {
+			return err
+		}
+		w, err := wiki.FromQueryData(queryData)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return wiki.Watch(ctx, w, opts)
+	},
+}
+
+func init() {
+	flagSet := WatchCmd.Flags()
+	flagSet.StringVarP(&wikiName, "wiki", "w", "", "name of the wiki to watch")
+	flagSet.DurationVar(&interval, "interval", 5*time.Minute, "time between polls of the wiki's recent changes")
+	flagSet.IntSliceVarP(&namespaces, "namespace", "n", []int{0}, "namespace numbers of pages to watch (repeatable)")
+	flagSet.StringArrayVarP(&categories, "category", "c", nil, "only scrape pages in this category (repeatable)")
+	flagSet.StringVarP(&manFile, "from-manifest", "f", "", "only scrape pages listed in this manifest file")
+	flagSet.StringVar(&statePath, "state", "wikiscrape-watch.json", "file saving the last change seen")
+	flagSet.StringVarP(&outDir, "out-dir", "o", "", "write each page to a JSON file in this directory")
+	WatchCmd.MarkFlagRequired("wiki")
+}
@@ -1,12 +1,14 @@
 module github.com/mal0ner/wikiscrape
 
-go 1.21.6
+go 1.22
 
 require (
 	github.com/PuerkitoBio/goquery v1.8.1
 	github.com/json-iterator/go v1.1.12
+	github.com/klauspost/compress v1.18.0
 	github.com/sirupsen/logrus v1.9.3
 	github.com/spf13/cobra v1.8.0
+	github.com/ulikunitz/xz v0.5.12
 )
 
 require (
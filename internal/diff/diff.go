@@ -0,0 +1,183 @@
+// Package diff compares two versions of a scraped page section by section,
+// reporting added, removed and changed sections along with line level
+// differences within changed sections.
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+// Status describes how a section differs between two versions of a page.
+type Status string
+
+const (
+	Added   Status = "added"
+	Removed Status = "removed"
+	Changed Status = "changed"
+)
+
+// Op describes how a line differs between two versions of a section.
+type Op string
+
+const (
+	Insert Op = "+"
+	Delete Op = "-"
+)
+
+// PageDiff lists the sections which differ between two versions of a page,
+// in page order. Sections which are the same in both versions are omitted.
+type PageDiff struct {
+	Title        string         `json:"title"`
+	FromRevision int            `json:"fromRevision,omitempty"`
+	ToRevision   int            `json:"toRevision,omitempty"`
+	Sections     []*SectionDiff `json:"sections"`
+}
+
+// SectionDiff describes a section which differs between two versions of a
+// page. Lines holds the lines of its content which were inserted or deleted;
+// every line of an added or removed section is listed.
+type SectionDiff struct {
+	Heading string      `json:"heading"`
+	Status  Status      `json:"status"`
+	Lines   []*LineDiff `json:"lines"`
+}
+
+// LineDiff is a single inserted or deleted line of section content.
+type LineDiff struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Pages compares two versions of a page. Sections are matched by heading;
+// when a heading occurs more than once, occurrences are matched in order.
+func Pages(from *scrape.Page, to *scrape.Page) *PageDiff {
+	result := &PageDiff{
+		Title:        to.Title,
+		FromRevision: from.Revision,
+		ToRevision:   to.Revision,
+		Sections:     []*SectionDiff{},
+	}
+	fromKeys, toKeys := sectionKeys(from.Sections), sectionKeys(to.Sections)
+	for _, e := range lcs(fromKeys, toKeys) {
+		switch {
+		case e.a < 0:
+			s := to.Sections[e.b]
+			result.Sections = append(result.Sections, &SectionDiff{
+				Heading: s.Heading,
+				Status:  Added,
+				Lines:   Lines(nil, splitLines(s.Content)),
+			})
+		case e.b < 0:
+			s := from.Sections[e.a]
+			result.Sections = append(result.Sections, &SectionDiff{
+				Heading: s.Heading,
+				Status:  Removed,
+				Lines:   Lines(splitLines(s.Content), nil),
+			})
+		default:
+			lines := Lines(splitLines(from.Sections[e.a].Content), splitLines(to.Sections[e.b].Content))
+			if len(lines) > 0 {
+				result.Sections = append(result.Sections, &SectionDiff{
+					Heading: to.Sections[e.b].Heading,
+					Status:  Changed,
+					Lines:   lines,
+				})
+			}
+		}
+	}
+	return result
+}
+
+// Lines returns the lines deleted from a and inserted into b, in order, with
+// the deletions of each changed run of lines before its insertions.
+func Lines(a []string, b []string) []*LineDiff {
+	lines := []*LineDiff{}
+	var inserted []*LineDiff
+	for _, e := range lcs(a, b) {
+		switch {
+		case e.a < 0:
+			inserted = append(inserted, &LineDiff{Op: Insert, Text: b[e.b]})
+		case e.b < 0:
+			lines = append(lines, &LineDiff{Op: Delete, Text: a[e.a]})
+		default:
+			lines = append(lines, inserted...)
+			inserted = nil
+		}
+	}
+	return append(lines, inserted...)
+}
+
+// sectionKeys returns a key identifying each section by its heading and the
+// number of earlier sections with the same heading.
+func sectionKeys(sections []*scrape.Section) []string {
+	seen := map[string]int{}
+	keys := make([]string, len(sections))
+	for i, s := range sections {
+		heading := strings.ToLower(strings.TrimSpace(s.Heading))
+		keys[i] = fmt.Sprintf("%s#%d", heading, seen[heading])
+		seen[heading]++
+	}
+	return keys
+}
+
+// splitLines splits section content into its non empty lines.
+func splitLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// edit is a step of an edit script: an element kept from both sequences,
+// deleted from a (b < 0) or inserted from b (a < 0).
+type edit struct {
+	a, b int
+}
+
+// lcs returns an edit script turning a into b which keeps their longest
+// common subsequence.
+func lcs(a []string, b []string) []edit {
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			edits = append(edits, edit{i, -1})
+			i++
+		default:
+			edits = append(edits, edit{-1, j})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{i, -1})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{-1, j})
+	}
+	return edits
+}
@@ -0,0 +1,74 @@
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/diff"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+func TestLines(t *testing.T) {
+	got := diff.Lines([]string{"a", "b", "c", "d"}, []string{"a", "x", "c", "d", "e"})
+	want := []*diff.LineDiff{
+		{Op: diff.Delete, Text: "b"},
+		{Op: diff.Insert, Text: "x"},
+		{Op: diff.Insert, Text: "e"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Line diff mismatch.\nGot: %+v\nWant: %+v", got, want)
+	}
+	if got := diff.Lines([]string{"a"}, []string{"a"}); len(got) != 0 {
+		t.Errorf("Expected no differences between equal lines, got %+v", got)
+	}
+}
+
+func TestPages(t *testing.T) {
+	from := &scrape.Page{
+		Title:    "Bear",
+		Revision: 1,
+		Sections: []*scrape.Section{
+			{Heading: "Introduction", Index: 0, Content: "Bears are mammals.\n"},
+			{Heading: "Diet", Index: 1, Content: "Bears eat fish.\nBears eat berries.\n"},
+			{Heading: "Habitat", Index: 2, Content: "Forests.\n"},
+			{Heading: "Notes", Index: 3, Content: "First note.\n"},
+		},
+	}
+	to := &scrape.Page{
+		Title:    "Bear",
+		Revision: 2,
+		Sections: []*scrape.Section{
+			{Heading: "Introduction", Index: 0, Content: "Bears are mammals.\n"},
+			{Heading: "Diet", Index: 1, Content: "Bears eat fish.\n\nBears eat honey.\n"},
+			{Heading: "Behaviour", Index: 2, Content: "Bears hibernate.\n"},
+			{Heading: "Notes", Index: 3, Content: "First note.\n"},
+			{Heading: "Notes", Index: 4, Content: "Second note.\n"},
+		},
+	}
+	got := diff.Pages(from, to)
+	want := &diff.PageDiff{
+		Title:        "Bear",
+		FromRevision: 1,
+		ToRevision:   2,
+		Sections: []*diff.SectionDiff{
+			{Heading: "Diet", Status: diff.Changed, Lines: []*diff.LineDiff{
+				{Op: diff.Delete, Text: "Bears eat berries."},
+				{Op: diff.Insert, Text: "Bears eat honey."},
+			}},
+			{Heading: "Habitat", Status: diff.Removed, Lines: []*diff.LineDiff{{Op: diff.Delete, Text: "Forests."}}},
+			{Heading: "Behaviour", Status: diff.Added, Lines: []*diff.LineDiff{{Op: diff.Insert, Text: "Bears hibernate."}}},
+			{Heading: "Notes", Status: diff.Added, Lines: []*diff.LineDiff{{Op: diff.Insert, Text: "Second note."}}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Page diff mismatch.\nGot: %+v\nWant: %+v", got, want)
+		for _, s := range got.Sections {
+			t.Logf("%s %s %+v", s.Status, s.Heading, s.Lines)
+		}
+	}
+
+	// Equal pages have no differences
+	if got := diff.Pages(to, to); len(got.Sections) != 0 {
+		t.Errorf("Expected no differences between equal pages, got %+v", got.Sections)
+	}
+}
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/logging"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Default is the exporter used by wikis, printing pages to stdout unless
+// replaced by the command line.
+var Default Exporter = &TestExporter{}
+
+// PageFile is the JSON representation of a page written by DirExporter.
+// Revision is the id of the revision the page was scraped from, and Filter
+// the section filter it was scraped with, used by the sync command to find
+// pages which changed since and scrape them the same way again.
+type PageFile struct {
+	Title       string                 `json:"title"`
+	Revision    int                    `json:"revision,omitempty"`
+	Filter      *PageFilter            `json:"filter,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Thumbnail   string                 `json:"thumbnail,omitempty"`
+	Infobox     []*scrape.InfoboxField `json:"infobox,omitempty"`
+	Sections    []*scrape.Section      `json:"sections"`
+}
+
+// PageFilter is the JSON representation of a scrape.SectionFilter, holding
+// patterns as the regular expressions they were given as.
+type PageFilter struct {
+	Headings []string `json:"headings,omitempty"`
+	Indices  []int    `json:"indices,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Exclude  []string `json:"exclude,omitempty"`
+}
+
+// newPageFilter returns the representation of the filter, or nil when the
+// filter keeps every section.
+func newPageFilter(filter *scrape.SectionFilter) *PageFilter {
+	if filter.IsEmpty() {
+		return nil
+	}
+	return &PageFilter{
+		Headings: filter.Headings,
+		Indices:  filter.Indices,
+		Patterns: expressions(filter.Patterns),
+		Exclude:  expressions(filter.Exclude),
+	}
+}
+
+// expressions returns the regular expressions patterns were compiled from
+// by scrape.NewSectionFilter, without the case-insensitive flag it adds.
+func expressions(patterns []*regexp.Regexp) []string {
+	var exprs []string
+	for _, re := range patterns {
+		exprs = append(exprs, strings.TrimPrefix(re.String(), "(?i)"))
+	}
+	return exprs
+}
+
+// SectionFilter rebuilds the filter the page was scraped with. A nil
+// PageFilter gives a nil filter, keeping every section.
+//
+// Can error when:
+//   - A pattern or exclusion is not a valid regular expression
+func (pf *PageFilter) SectionFilter() (*scrape.SectionFilter, error) {
+	if pf == nil {
+		return nil, nil
+	}
+	return scrape.NewSectionFilter(pf.Headings, pf.Indices, pf.Patterns, pf.Exclude)
+}
+
+// DirExporter writes each page to its own JSON file in Dir, named after
+// the page title by FileName. A page exported again replaces its file.
+type DirExporter struct {
+	Dir string
+}
+
+// Export writes the page to Dir, creating the directory if needed. The
+// Exporter interface cannot return errors, so failures are logged.
+func (de *DirExporter) Export(page *scrape.Page) {
+	if err := WritePage(de.Dir, page); err != nil {
+		logging.Log.Errorf("exporting %s: %v", page.Title, err)
+	}
+}
+
+// FileName returns the name of the file a page with the given title is
+// written to. Spaces become underscores and characters which cannot
+// appear in a file name, such as "/", are escaped.
+func FileName(title string) string {
+	return url.PathEscape(strings.ReplaceAll(title, " ", "_")) + ".json"
+}
+
+// WritePage writes the page to its file in dir.
+func WritePage(dir string, page *scrape.Page) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(&PageFile{
+		Title:       page.Title,
+		Revision:    page.Revision,
+		Filter:      newPageFilter(page.Filter),
+		Description: page.Description,
+		Thumbnail:   page.Thumbnail,
+		Infobox:     page.Infobox,
+		Sections:    page.Sections,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, FileName(page.Title)), append(content, '\n'), 0644)
+}
+
+// ReadDir reads every page file in dir, keyed by file name. Files which
+// are not JSON page files are skipped.
+func ReadDir(dir string) (map[string]*PageFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pages := map[string]*PageFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var page PageFile
+		if err := json.Unmarshal(content, &page); err != nil || page.Title == "" {
+			logging.Log.Warnf("skipping %s: not an exported page", entry.Name())
+			continue
+		}
+		pages[entry.Name()] = &page
+	}
+	return pages, nil
+}
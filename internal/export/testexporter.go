@@ -10,6 +10,22 @@ type TestExporter struct{}
 
 func (te *TestExporter) Export(page *scrape.Page) {
 	fmt.Println("Title: " + page.Title)
+	if page.Revision != 0 {
+		fmt.Printf("Revision: %d\n", page.Revision)
+	}
+	if page.Description != "" {
+		fmt.Println("Description: " + page.Description)
+	}
+	if page.Thumbnail != "" {
+		fmt.Println("Thumbnail: " + page.Thumbnail)
+	}
+	for _, f := range page.Infobox {
+		if f.Group != "" {
+			fmt.Printf("Infobox: [%s] %s: %s\n", f.Group, f.Label, f.Value)
+			continue
+		}
+		fmt.Printf("Infobox: %s: %s\n", f.Label, f.Value)
+	}
 	for _, s := range page.Sections {
 		fmt.Println("Section: " + s.Heading + "--------------------------------------\n")
 		fmt.Println(s.Content + "\n")
@@ -0,0 +1,127 @@
+package scrape
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	asciiDocHeading   = regexp.MustCompile(`^(={1,6}|#{1,6})\s+(.*?)\s*$`)
+	asciiDocDelimiter = regexp.MustCompile(`^(-{4,}|\.{4,}|={4,}|\*{4,}|_{4,}|\+{4,}|/{4,}|\|===+)\s*$`)
+	asciiDocListItem  = regexp.MustCompile(`^\s*(\*+|-|\.+|\d+\.)\s|^[^\s].*?(::|;;)(\s|$)`)
+	asciiDocMacro     = regexp.MustCompile(`(?:https?://|mailto:|link:|xref:)[^\s\[]*\[([^\]]*)\]`)
+	asciiDocImage     = regexp.MustCompile(`image:[^\s\[]*\[[^\]]*\]`)
+	asciiDocXref      = regexp.MustCompile(`<<([^,>]*)(?:,\s*([^>]*))?>>`)
+	asciiDocFormat    = regexp.MustCompile("[*_`#]{1,2}([^*_`#\n]+)[*_`#]{1,2}")
+	asciiDocAttribute = regexp.MustCompile(`^:[^:]+:`)
+)
+
+// ParseAsciiDoc splits an AsciiDoc document into a Page, following the same
+// rules as ParseMarkdown: the document title ("= Title") becomes the page
+// title, the next heading level forms the sections, and deeper headings are
+// folded into their parent section. Only paragraph text is kept; delimited
+// blocks, lists, tables, attributes and images are discarded and links and
+// cross references are reduced to their text.
+func ParseAsciiDoc(text string) *Page {
+	var intro string
+	var headings []htmlHeading
+	var body strings.Builder
+	flush := func() {
+		content := asciiDocToText(body.String())
+		body.Reset()
+		if len(headings) == 0 {
+			intro = content
+			return
+		}
+		headings[len(headings)-1].Content = content
+	}
+	delimiter := ""
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if delimiter != "" {
+			if trimmed == delimiter {
+				delimiter = ""
+			}
+			continue
+		}
+		if asciiDocDelimiter.MatchString(trimmed) {
+			delimiter = trimmed
+			body.WriteByte('\n')
+			continue
+		}
+		if m := asciiDocHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			headings = append(headings, htmlHeading{
+				Level: len(m[1]),
+				Text:  strings.TrimSpace(asciiDocInline(m[2])),
+			})
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+	return foldHeadings(intro, headings)
+}
+
+// isAsciiDocParagraph reports whether line holds paragraph text rather than
+// some other block, such as a list item, block attribute or comment.
+func isAsciiDocParagraph(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "", strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+		return false
+	case strings.HasPrefix(trimmed, "//"), strings.HasPrefix(trimmed, "["), strings.HasPrefix(trimmed, "|"):
+		return false
+	case strings.HasPrefix(trimmed, "image::"), strings.HasPrefix(trimmed, "include::"), strings.HasPrefix(trimmed, "toc::"):
+		return false
+	case len(trimmed) > 1 && trimmed[0] == '.' && trimmed[1] != '.' && trimmed[1] != ' ':
+		// Block title
+		return false
+	case asciiDocAttribute.MatchString(trimmed), asciiDocListItem.MatchString(line):
+		return false
+	}
+	return true
+}
+
+// asciiDocInline strips inline AsciiDoc formatting from a line of text.
+func asciiDocInline(text string) string {
+	text = asciiDocImage.ReplaceAllString(text, "")
+	text = asciiDocMacro.ReplaceAllString(text, "$1")
+	text = asciiDocXref.ReplaceAllStringFunc(text, func(xref string) string {
+		m := asciiDocXref.FindStringSubmatch(xref)
+		if m[2] != "" {
+			return m[2]
+		}
+		return m[1]
+	})
+	text = asciiDocFormat.ReplaceAllString(text, "$1")
+	text = strings.TrimSuffix(text, " +")
+	return html.UnescapeString(text)
+}
+
+// asciiDocToText reduces a fragment of AsciiDoc to its paragraph text.
+// Each paragraph is terminated by a newline.
+func asciiDocToText(text string) string {
+	var out strings.Builder
+	var paragraph []string
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString(strings.Join(paragraph, " "))
+			out.WriteByte('\n')
+			paragraph = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if !isAsciiDocParagraph(line) {
+			endParagraph()
+			continue
+		}
+		if line := strings.TrimSpace(asciiDocInline(line)); line != "" {
+			paragraph = append(paragraph, line)
+		}
+	}
+	endParagraph()
+	return out.String()
+}
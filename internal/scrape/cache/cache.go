@@ -0,0 +1,433 @@
+// Package cache implements an on-disk store of wiki API responses, keyed
+// by the full request URL (wiki, page and parameters), request body and
+// credentials, so that pages do not need to be downloaded again when
+// re-running a scrape. Entries older than
+// the cache TTL are revalidated with conditional requests using their ETag
+// or Last-Modified headers when the server provided them. Scrapers may
+// choose their own freshness per request: DoWithin serves entries of any
+// maximum age, such as responses which can never change, and DoLive
+// bypasses the cache for responses which change with every edit.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Default is the cache used by scrapers created by the wiki package.
+// A nil Default disables caching.
+var Default *Cache
+
+// Cache stores API responses as JSON files beneath Dir. Entries younger
+// than TTL are served without contacting the wiki. An Offline cache never
+// contacts the wiki and serves every entry regardless of its age.
+type Cache struct {
+	Dir     string
+	TTL     time.Duration
+	Offline bool
+}
+
+// MissError indicates that a response was needed from the cache
+// while offline, but was never stored.
+type MissError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted MissError including code and
+// additional information.
+func (e *MissError) Error() string {
+	return fmt.Sprintf("CacheMissError: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// Entry is a single cached response along with the information needed
+// to inspect and revalidate it. Auth identifies the credentials the
+// request was made with, if any, so that responses are never served to a
+// request made with other credentials.
+type Entry struct {
+	Key          string    `json:"key"`
+	URL          string    `json:"url"`
+	RequestBody  string    `json:"requestBody,omitempty"`
+	Auth         string    `json:"auth,omitempty"`
+	Wiki         string    `json:"wiki"`
+	Page         string    `json:"page"`
+	StoredAt     time.Time `json:"storedAt"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Body         string    `json:"body"`
+}
+
+// New creates a Cache storing entries beneath dir which are considered
+// fresh for ttl.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+// DefaultDir returns the directory used for the cache when none is given,
+// a "wikiscrape" directory in the user's cache directory.
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "wikiscrape")
+}
+
+// Key returns the cache key for a request URL, its body and the identity
+// of its credentials, if any.
+func Key(rawURL string, body []byte, auth string) string {
+	h := sha256.New()
+	h.Write([]byte(rawURL))
+	if len(body) > 0 {
+		h.Write([]byte{'\n'})
+		h.Write(body)
+	}
+	if auth != "" {
+		h.Write([]byte("\nauth:" + auth))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Identity returns the identity under which responses to req are cached:
+// a hash of its Authorization header, or "" for anonymous requests. The
+// credentials themselves are never stored.
+func Identity(req *http.Request) string {
+	credentials := req.Header.Get("Authorization")
+	if credentials == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(credentials))
+	return hex.EncodeToString(sum[:])
+}
+
+// path returns the location of the file storing the entry for key. Entries
+// are spread across subdirectories named by the first two key characters.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// Age returns how long ago the entry was stored or last revalidated.
+func (e *Entry) Age() time.Duration {
+	return time.Since(e.StoredAt)
+}
+
+// Get returns the entry stored for a request to rawURL with the given
+// body and credentials identity, or nil if there is none.
+func (c *Cache) Get(rawURL string, body []byte, auth string) (*Entry, error) {
+	content, err := os.ReadFile(c.path(Key(rawURL, body, auth)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Put stores an entry, replacing any previous entry for the same request.
+func (c *Cache) Put(entry *Entry) error {
+	entry.Key = Key(entry.URL, []byte(entry.RequestBody), entry.Auth)
+	path := c.path(entry.Key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Remove deletes the entry stored for a request to rawURL with the
+// given body and credentials identity, if any.
+func (c *Cache) Remove(rawURL string, body []byte, auth string) error {
+	err := os.Remove(c.path(Key(rawURL, body, auth)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// StatusError indicates that a request received an unsuccessful response.
+// The body is kept, as APIs often describe the failure within it.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+// Error returns a formatted StatusError including the status code and url.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d %s from %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// Fetch returns the body of a GET request to rawURL through the cache.
+// Fetch may be called on a nil Cache to make an uncached request.
+func (c *Cache) Fetch(client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(client, req, nil)
+}
+
+// FetchWithin returns the body of a GET request to rawURL through the
+// cache, serving entries younger than maxAge rather than the cache TTL
+// without contacting the wiki. See DoWithin.
+func (c *Cache) FetchWithin(client *http.Client, rawURL string, maxAge time.Duration) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.DoWithin(client, req, nil, maxAge)
+}
+
+// FetchLive returns the body of a GET request to rawURL without the cache.
+// See DoLive.
+func (c *Cache) FetchLive(client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.DoLive(client, req, nil)
+}
+
+// Do sends a request through the cache and returns the response body. The
+// request body, if any, must be given as body rather than set on req, so
+// that it can form part of the cache key. Request headers, such as
+// credentials, are sent but never stored.
+//
+// Fresh entries are served from the cache. Stale entries are revalidated
+// with a conditional request and refreshed on a 304 Not Modified response.
+// Successful responses are stored for next time. Do may be called on a nil
+// Cache to make an uncached request.
+//
+// Can error when:
+//   - The cache is Offline and has no entry for the request (MissError)
+//   - The response status is not 200 OK (StatusError)
+func (c *Cache) Do(client *http.Client, req *http.Request, body []byte) ([]byte, error) {
+	var ttl time.Duration
+	if c != nil {
+		ttl = c.TTL
+	}
+	return c.DoWithin(client, req, body, ttl)
+}
+
+// DoLive sends a request without reading or storing cache entries, for
+// responses which change too often to be cached, such as lists of recent
+// edits. DoLive may be called on a nil Cache.
+//
+// Can error when:
+//   - The cache is Offline, as the response was never stored (MissError)
+//   - The response status is not 200 OK (StatusError)
+func (c *Cache) DoLive(client *http.Client, req *http.Request, body []byte) ([]byte, error) {
+	if c != nil && c.Offline {
+		return nil, &MissError{
+			Code: "offlinelive",
+			Info: fmt.Sprintf("Responses for %s are never cached, run without --offline", describe(req.URL.String())),
+		}
+	}
+	var uncached *Cache
+	return uncached.Do(client, req, body)
+}
+
+// Touch marks an entry as fresh again, for callers which have revalidated
+// it by other means than a conditional request.
+func (c *Cache) Touch(entry *Entry) error {
+	entry.StoredAt = time.Now()
+	return c.Put(entry)
+}
+
+// DoWithin is Do, except that entries younger than maxAge rather than the
+// cache TTL are served without contacting the wiki. A maxAge of 0 always
+// contacts the wiki, while responses which can never change, such as a
+// specific revision of a page, may be served whatever their age.
+func (c *Cache) DoWithin(client *http.Client, req *http.Request, body []byte, maxAge time.Duration) ([]byte, error) {
+	rawURL := req.URL.String()
+	auth := Identity(req)
+	var entry *Entry
+	if c != nil {
+		var err error
+		entry, err = c.Get(rawURL, body, auth)
+		if err != nil {
+			return nil, err
+		}
+		if c.Offline {
+			if entry == nil {
+				return nil, &MissError{
+					Code: "offlinemiss",
+					Info: fmt.Sprintf("No cached response for %s, fetch it once without --offline first", describe(rawURL)),
+				}
+			}
+			return []byte(entry.Body), nil
+		}
+		if entry != nil && entry.Age() < maxAge {
+			return []byte(entry.Body), nil
+		}
+	}
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified && entry != nil {
+		return []byte(entry.Body), c.Touch(entry)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: rawURL, StatusCode: res.StatusCode, Body: resBody}
+	}
+	if c != nil {
+		entry := newEntry(rawURL, res, resBody)
+		entry.RequestBody = string(body)
+		entry.Auth = auth
+		err = c.Put(entry)
+	}
+	return resBody, err
+}
+
+// newEntry builds an entry for a response, recording the wiki host and the
+// page or titles requested so the cache can be inspected.
+func newEntry(rawURL string, res *http.Response, body []byte) *Entry {
+	wiki, page := requestInfo(rawURL)
+	return &Entry{
+		URL:          rawURL,
+		Wiki:         wiki,
+		Page:         page,
+		StoredAt:     time.Now(),
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Body:         string(body),
+	}
+}
+
+// requestInfo returns the wiki host of a request URL along with the page
+// or titles it requests, if any.
+func requestInfo(rawURL string) (string, string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", ""
+	}
+	params := parsed.Query()
+	page := params.Get("page")
+	if page == "" {
+		page = params.Get("titles")
+	}
+	return parsed.Host, page
+}
+
+// describe summarises a request URL by its wiki host and requested page
+// for use in error messages.
+func describe(rawURL string) string {
+	wiki, page := requestInfo(rawURL)
+	if page == "" {
+		return rawURL
+	}
+	return fmt.Sprintf("page %q on %s", page, wiki)
+}
+
+// List returns every entry in the cache, oldest first.
+func (c *Cache) List() ([]*Entry, error) {
+	var entries []*Entry
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var entry Entry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			return fmt.Errorf("corrupt cache entry %s: %w", path, err)
+		}
+		entries = append(entries, &entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StoredAt.Before(entries[j].StoredAt)
+	})
+	return entries, nil
+}
+
+// Prune removes every entry older than maxAge and returns the number
+// of entries removed.
+func (c *Cache) Prune(maxAge time.Duration) (int, error) {
+	entries, err := c.List()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.Age() < maxAge {
+			continue
+		}
+		if err := c.Remove(entry.URL, []byte(entry.RequestBody), entry.Auth); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Clear removes the cache directory and every entry within it.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
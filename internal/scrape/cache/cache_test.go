@@ -0,0 +1,235 @@
+package cache_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+func TestCacheFetch(t *testing.T) {
+	requests := 0
+	revalidated := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"parse":{"title":"Bear"}}`)
+	}))
+	defer server.Close()
+	c := cache.New(t.TempDir(), time.Hour)
+	pageURL := server.URL + "?action=parse&page=Bear"
+
+	// Test 1: Miss, then fresh hit
+	for i := 0; i < 2; i++ {
+		body, err := c.Fetch(server.Client(), pageURL)
+		if err != nil {
+			t.Fatalf("Failed to fetch: %v", err)
+		}
+		if string(body) != `{"parse":{"title":"Bear"}}` {
+			t.Errorf("Body mismatch. Got: %s", body)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Request count mismatch. Got: %d, Want: 1", requests)
+	}
+	entry, err := c.Get(pageURL, nil, "")
+	if err != nil || entry == nil {
+		t.Fatalf("Expected a cached entry, got %v, %v", entry, err)
+	}
+	if entry.Page != "Bear" || entry.ETag != `"v1"` {
+		t.Errorf("Unexpected entry metadata: %+v", entry)
+	}
+
+	// Test 2: Stale entry is revalidated
+	c.TTL = 0
+	body, err := c.Fetch(server.Client(), pageURL)
+	if err != nil {
+		t.Fatalf("Failed to fetch: %v", err)
+	}
+	if string(body) != `{"parse":{"title":"Bear"}}` || revalidated != 1 {
+		t.Errorf("Expected a revalidated cached body, got %s after %d revalidations", body, revalidated)
+	}
+}
+
+func TestCacheManagement(t *testing.T) {
+	c := cache.New(t.TempDir(), time.Hour)
+	old := &cache.Entry{URL: "https://a/?page=Old", StoredAt: time.Now().Add(-2 * time.Hour)}
+	recent := &cache.Entry{URL: "https://a/?page=Recent", StoredAt: time.Now()}
+	for _, e := range []*cache.Entry{recent, old} {
+		if err := c.Put(e); err != nil {
+			t.Fatalf("Failed to store entry: %v", err)
+		}
+	}
+
+	// Test 1: List oldest first
+	entries, err := c.List()
+	if err != nil {
+		t.Fatalf("Failed to list entries: %v", err)
+	}
+	if len(entries) != 2 || entries[0].URL != old.URL {
+		t.Fatalf("Unexpected entries: %v", entries)
+	}
+
+	// Test 2: Prune
+	removed, err := c.Prune(time.Hour)
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 entry pruned, got %d, %v", removed, err)
+	}
+
+	// Test 3: Clear
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Failed to clear cache: %v", err)
+	}
+	entries, err = c.List()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected an empty cache, got %d entries, %v", len(entries), err)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	c := cache.New(t.TempDir(), 0)
+	c.Offline = true
+	stored := &cache.Entry{URL: "http://127.0.0.1:0/?page=Bear", StoredAt: time.Now().Add(-24 * time.Hour), Body: "cached"}
+	if err := c.Put(stored); err != nil {
+		t.Fatalf("Failed to store entry: %v", err)
+	}
+
+	// Test 1: Stale entries are served without contacting the wiki
+	body, err := c.Fetch(http.DefaultClient, stored.URL)
+	if err != nil || string(body) != "cached" {
+		t.Errorf("Expected the cached body, got %s, %v", body, err)
+	}
+
+	// Test 2: Misses fail clearly
+	_, err = c.Fetch(http.DefaultClient, "http://127.0.0.1:0/?page=Wolf")
+	if _, ok := err.(*cache.MissError); !ok {
+		t.Errorf("Expected a MissError, got %v", err)
+	}
+}
+
+func TestCacheFetchStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "missing")
+	}))
+	defer server.Close()
+
+	// Test 1: Unsuccessful responses are errors and are not stored
+	c := cache.New(t.TempDir(), time.Hour)
+	_, err := c.Fetch(server.Client(), server.URL)
+	statusErr, ok := err.(*cache.StatusError)
+	if !ok || statusErr.StatusCode != http.StatusNotFound || string(statusErr.Body) != "missing" {
+		t.Errorf("Expected a 404 StatusError, got %v", err)
+	}
+	if entry, _ := c.Get(server.URL, nil, ""); entry != nil {
+		t.Error("Expected an unsuccessful response not to be cached")
+	}
+
+	// Test 2: A nil cache makes uncached requests
+	var nilCache *cache.Cache
+	_, err = nilCache.Fetch(server.Client(), server.URL)
+	if _, ok := err.(*cache.StatusError); !ok {
+		t.Errorf("Expected a StatusError from a nil cache, got %v", err)
+	}
+}
+
+func TestCacheDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Header.Get("Authorization"), body)
+	}))
+	defer server.Close()
+	c := cache.New(t.TempDir(), time.Hour)
+	post := func(token string, body string) (*http.Request, string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+		if err != nil {
+			t.Fatalf("Failed to build request: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		got, err := c.Do(server.Client(), req, []byte(body))
+		if err != nil {
+			t.Fatalf("Failed to post: %v", err)
+		}
+		return req, string(got)
+	}
+
+	// Test 1: Requests to the same URL with different bodies are cached apart
+	if _, got := post("secret", "a"); got != "secret a" {
+		t.Errorf("Body mismatch. Got: %s", got)
+	}
+	req, got := post("secret", "b")
+	if got != "secret b" {
+		t.Errorf("Body mismatch. Got: %s", got)
+	}
+	entry, err := c.Get(server.URL, []byte("a"), cache.Identity(req))
+	if err != nil || entry == nil {
+		t.Fatalf("Expected a cached entry, got %v, %v", entry, err)
+	}
+	if entry.RequestBody != "a" || entry.Body != "secret a" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	// Test 2: Responses are cached apart for each credential, which is not stored
+	if entry.Auth == "" || strings.Contains(entry.Auth, "secret") {
+		t.Errorf("Expected a hashed credential identity, got %q", entry.Auth)
+	}
+	if _, got := post("other", "a"); got != "other a" {
+		t.Errorf("Expected a response for other credentials, got %s", got)
+	}
+	if _, got := post("", "a"); got != " a" {
+		t.Errorf("Expected an anonymous response, got %s", got)
+	}
+	if _, got := post("secret", "a"); got != "secret a" {
+		t.Errorf("Body mismatch. Got: %s", got)
+	}
+}
+
+func TestCacheFreshness(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, "response %d", requests)
+	}))
+	defer server.Close()
+	c := cache.New(t.TempDir(), 0)
+
+	// Test 1: Entries younger than maxAge are served whatever the TTL
+	for i := 0; i < 2; i++ {
+		body, err := c.FetchWithin(server.Client(), server.URL, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to fetch: %v", err)
+		}
+		if string(body) != "response 1" {
+			t.Errorf("Body mismatch. Got: %s", body)
+		}
+	}
+
+	// Test 2: Live requests neither read nor store entries
+	body, err := c.FetchLive(server.Client(), server.URL)
+	if err != nil || string(body) != "response 2" {
+		t.Errorf("Expected a live response, got %s (%v)", body, err)
+	}
+	if entry, _ := c.Get(server.URL, nil, ""); entry == nil || entry.Body != "response 1" {
+		t.Errorf("Expected the cached entry to be untouched, got %+v", entry)
+	}
+
+	// Test 3: Live requests fail offline
+	c.Offline = true
+	if _, err := c.FetchLive(server.Client(), server.URL); err == nil {
+		t.Error("Expected a MissError for a live request offline")
+	} else if _, ok := err.(*cache.MissError); !ok {
+		t.Errorf("Expected a MissError, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package scrape
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+// Confluence page paths holding a page id, as found in Confluence Cloud urls
+// ("SPACE/pages/12345/Title") or given directly ("12345").
+var confluencePageID = regexp.MustCompile(`^(?:[^/]+/pages/)?(\d+)(?:/.*)?$`)
+
+// Rendered Confluence macros and storage format macros which hold no
+// paragraph text worth keeping, such as the table of contents or code blocks.
+const (
+	confluenceViewIgnore    = ".toc-macro, .code, .preformatted, .expand-control, script, style"
+	confluenceStorageIgnore = `ac\:structured-macro[ac\:name="toc"], ac\:structured-macro[ac\:name="code"], ` +
+		`ac\:structured-macro[ac\:name="noformat"], ac\:structured-macro[ac\:name="children"], ` +
+		`ac\:structured-macro[ac\:name="jira"], ac\:parameter, ac\:plain-text-body`
+)
+
+// Wraps methods for retrieving and parsing pages on a Confluence Cloud or
+// Server site through its REST content API. BaseURL is the site's context
+// path, such as "https://example.atlassian.net/wiki", under which the API is
+// found at "/rest/api". Requests are authenticated with Token, either as a
+// personal access token (Server and Data Center), or with basic auth as the
+// API token of User (Cloud). API responses are cached when Cache is not nil,
+// apart for each set of credentials.
+type ConfluenceScraper struct {
+	BaseURL string
+	User    string
+	Token   string
+	Cache   *cache.Cache
+}
+
+// Representation of a Confluence content object with its storage and
+// view bodies expanded.
+type confluenceContent struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Body  struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+		View struct {
+			Value string `json:"value"`
+		} `json:"view"`
+	} `json:"body"`
+}
+
+// ConfluenceError indicates that a Confluence page could not be retrieved.
+type ConfluenceError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted Confluence error including code and
+// additional information.
+func (e *ConfluenceError) Error() string {
+	return fmt.Sprintf("Confluence error: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// fetch makes an authenticated request to the content API and unmarshals
+// the json response into result.
+func (s *ConfluenceScraper) fetch(endpoint string, params url.Values, result any) error {
+	reqURL := strings.TrimSuffix(s.BaseURL, "/") + "/rest/api/content" + endpoint + "?" + params.Encode()
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.User != "" {
+		req.SetBasicAuth(s.User, s.Token)
+	} else if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	body, err := s.Cache.Do(http.DefaultClient, req, nil)
+	if statusErr, ok := err.(*cache.StatusError); ok {
+		var details struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(statusErr.Body, &details)
+		if details.Message == "" {
+			details.Message = http.StatusText(statusErr.StatusCode)
+		}
+		return &ConfluenceError{
+			Code: fmt.Sprintf("http%d", statusErr.StatusCode),
+			Info: details.Message,
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, result)
+}
+
+// fetchContent looks up the page specified by path, which is either a page
+// id, or a space key and page title separated by a slash ("DOCS/Onboarding").
+func (s *ConfluenceScraper) fetchContent(path string) (*confluenceContent, error) {
+	path, err := url.QueryUnescape(path)
+	if err != nil {
+		return nil, err
+	}
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	params := url.Values{}
+	params.Set("expand", "body.storage,body.view")
+	if m := confluencePageID.FindStringSubmatch(path); m != nil {
+		var content confluenceContent
+		if err := s.fetch("/"+m[1], params, &content); err != nil {
+			return nil, err
+		}
+		return &content, nil
+	}
+	space, title, ok := strings.Cut(path, "/")
+	if !ok {
+		return nil, &ConfluenceError{
+			Code: "invalidpage",
+			Info: fmt.Sprintf("The page %s must be a page id or a space key and title, such as DOCS/Onboarding", path),
+		}
+	}
+	params.Set("spaceKey", space)
+	params.Set("title", title)
+	params.Set("type", "page")
+	var results struct {
+		Results []*confluenceContent `json:"results"`
+	}
+	if err := s.fetch("", params, &results); err != nil {
+		return nil, err
+	}
+	if len(results.Results) == 0 {
+		return nil, &ConfluenceError{
+			Code: "pagenotfound",
+			Info: fmt.Sprintf("No page titled %s was found in space %s", title, space),
+		}
+	}
+	return results.Results[0], nil
+}
+
+// GetPage fetches the page specified by path and parses its rendered view
+// body into sections, falling back to the storage format when the view is
+// not available. Headings become sections and text inside macros such as
+// info panels is kept, while tables of contents and code blocks are dropped.
+//
+// Can error when:
+//   - page fetch fails, for example when the page does not exist or the
+//     token does not grant read access
+//   - section parsing fails
+func (s *ConfluenceScraper) GetPage(path string) (*Page, error) {
+	content, err := s.fetchContent(path)
+	if err != nil {
+		return nil, err
+	}
+	var page *Page
+	if content.Body.View.Value != "" {
+		page, err = parseHeadingHTML(content.Body.View.Value, confluenceViewIgnore)
+	} else {
+		page, err = parseHeadingHTML(content.Body.Storage.Value, confluenceStorageIgnore)
+	}
+	if err != nil {
+		return nil, err
+	}
+	page.Title = content.Title
+	return page, nil
+}
+
+// GetSections keeps the sections of the page specified by path selected by
+// the filter. Confluence returns the body of a page whole, so the page is
+// always fetched in full.
+func (s *ConfluenceScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
@@ -0,0 +1,150 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+const testConfluenceView = `<div class="toc-macro"><ul><li>Setup</li></ul></div>
+<p>Welcome aboard.</p>
+<h1 id="Onboarding-Setup">Setup</h1>
+<div class="confluence-information-macro"><div class="confluence-information-macro-body"><p>Ask for a laptop.</p></div></div>
+<div class="code panel"><pre>make setup</pre></div>
+<h2 id="Onboarding-Accounts">Accounts</h2>
+<p>Request access.</p>
+<h1 id="Onboarding-Contacts">Contacts</h1>
+<p>Ask the team.</p>`
+
+const testConfluenceStorage = `<p>Welcome aboard.</p>
+<ac:structured-macro ac:name="toc"><ac:parameter ac:name="maxLevel">2</ac:parameter></ac:structured-macro>
+<h1>Setup</h1>
+<ac:structured-macro ac:name="info"><ac:rich-text-body><p>Ask for a laptop.</p></ac:rich-text-body></ac:structured-macro>
+<ac:structured-macro ac:name="code"><ac:plain-text-body><![CDATA[make setup]]></ac:plain-text-body></ac:structured-macro>
+<h1>Contacts</h1>
+<p>Ask the team.</p>`
+
+func TestConfluenceScraper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "me@example.com" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"statusCode":401,"message":"Basic authentication with passwords is deprecated."}`)
+			return
+		}
+		content := map[string]any{"id": "12345", "title": "Onboarding"}
+		body := map[string]any{"storage": map[string]any{"value": testConfluenceStorage}}
+		switch r.URL.Path {
+		case "/wiki/rest/api/content":
+			query := r.URL.Query()
+			if query.Get("spaceKey") != "DOCS" || query.Get("title") != "Onboarding" {
+				fmt.Fprint(w, `{"results":[],"size":0}`)
+				return
+			}
+			body["view"] = map[string]any{"value": testConfluenceView}
+			content["body"] = body
+			jsoniter.NewEncoder(w).Encode(map[string]any{"results": []any{content}})
+		case "/wiki/rest/api/content/12345":
+			content["body"] = body
+			jsoniter.NewEncoder(w).Encode(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"statusCode":404,"message":"No content found with id: 999"}`)
+		}
+	}))
+	defer server.Close()
+	scraper := &scrape.ConfluenceScraper{BaseURL: server.URL + "/wiki", User: "me@example.com", Token: "secret"}
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "Welcome aboard."},
+		{Heading: "Setup", Index: 1, Content: "Ask for a laptop."},
+		{Heading: "Contacts", Index: 2, Content: "Ask the team."},
+	}
+	check := func(page *scrape.Page, wantSections []scrape.Section) {
+		t.Helper()
+		if page.Title != "Onboarding" || len(page.Sections) != len(wantSections) {
+			t.Fatalf("Unexpected page: %+v", page)
+		}
+		for i, s := range page.Sections {
+			if *s != wantSections[i] {
+				t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, wantSections[i])
+			}
+		}
+	}
+
+	// Test 1: Space key and title, parsed from the rendered view
+	page, err := scraper.GetPage("DOCS/Onboarding")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	viewWant := append([]scrape.Section{}, want...)
+	viewWant[1].Content = "Ask for a laptop.Request access."
+	check(page, viewWant)
+
+	// Test 2: Page id from a Cloud url path, parsed from the storage format
+	page, err = scraper.GetPage("DOCS/pages/12345/Onboarding")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	check(page, want)
+
+	// Test 3: Missing pages
+	for _, path := range []string{"DOCS/Missing", "999", "Onboarding"} {
+		if _, err := scraper.GetPage(path); err == nil {
+			t.Errorf("Expected an error for %s, got nil", path)
+		} else if _, ok := err.(*scrape.ConfluenceError); !ok {
+			t.Errorf("Expected a ConfluenceError for %s, got %v", path, err)
+		}
+	}
+
+	// Test 4: Bad credentials
+	scraper.Token = "wrong"
+	_, err = scraper.GetPage("12345")
+	if e, ok := err.(*scrape.ConfluenceError); !ok || e.Code != "http401" {
+		t.Errorf("Expected a ConfluenceError, got %v", err)
+	}
+}
+
+func TestConfluenceScraperCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Each token may only see its own space's version of the page.
+		title := "Onboarding for " + r.Header.Get("Authorization")
+		fmt.Fprintf(w, `{"id":"12345","title":%q,"body":{"storage":{"value":"<p>Welcome.</p>"}}}`, title)
+	}))
+	defer server.Close()
+	c := cache.New(t.TempDir(), time.Hour)
+	getTitle := func(token string) string {
+		scraper := &scrape.ConfluenceScraper{BaseURL: server.URL, Token: token, Cache: c}
+		page, err := scraper.GetPage("12345")
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		return page.Title
+	}
+
+	// Test 1: Two tokens are served and cached apart
+	if got := getTitle("alice"); got != "Onboarding for Bearer alice" {
+		t.Errorf("Title mismatch. Got: %s", got)
+	}
+	if got := getTitle("bob"); got != "Onboarding for Bearer bob" {
+		t.Errorf("Title mismatch. Got: %s", got)
+	}
+	entries, err := c.List()
+	if err != nil {
+		t.Fatalf("Failed to list cache: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Key == entries[1].Key {
+		t.Errorf("Expected two cache entries, got %d", len(entries))
+	}
+
+	// Test 2: Each token is then served its own cached response
+	if got := getTitle("alice"); got != "Onboarding for Bearer alice" || requests != 2 {
+		t.Errorf("Expected a cached response, got %s after %d requests", got, requests)
+	}
+}
@@ -0,0 +1,188 @@
+package scrape
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+// Selector matching every heading level.
+const htmlHeadings = "h1, h2, h3, h4, h5, h6"
+
+// Wraps methods for retrieving and parsing pages on a DokuWiki based
+// website. BaseURL is the wiki's doku.php script, pages are fetched as
+// rendered XHTML through its export_xhtmlbody action. API responses are
+// cached when Cache is not nil.
+type DokuWikiScraper struct {
+	BaseURL string
+	Cache   *cache.Cache
+}
+
+// DokuWikiError indicates that a DokuWiki page could not be retrieved.
+type DokuWikiError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted DokuWiki error including code and
+// additional information.
+func (e *DokuWikiError) Error() string {
+	return fmt.Sprintf("DokuWiki error: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// pageID converts a page name into a DokuWiki page id, which is lowercase
+// with underscores in place of spaces and colons separating namespaces.
+func pageID(path string) (string, error) {
+	id, err := url.QueryUnescape(path)
+	if err != nil {
+		return "", err
+	}
+	id = strings.ReplaceAll(strings.TrimSpace(id), " ", "_")
+	id = strings.ReplaceAll(id, "/", ":")
+	return strings.ToLower(strings.Trim(id, ":")), nil
+}
+
+// GetPage fetches the rendered XHTML of the page specified by path
+// and parses it into sections.
+//
+// Can error when:
+//   - page fetch fails, for example when the page does not exist
+//   - section parsing fails
+func (s *DokuWikiScraper) GetPage(path string) (*Page, error) {
+	id, err := pageID(path)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("id", id)
+	params.Set("do", "export_xhtmlbody")
+	body, err := s.Cache.Fetch(http.DefaultClient, s.BaseURL+"?"+params.Encode())
+	if statusErr, ok := err.(*cache.StatusError); ok {
+		return nil, &DokuWikiError{
+			Code: fmt.Sprintf("http%d", statusErr.StatusCode),
+			Info: fmt.Sprintf("The page %s could not be retrieved: %s", id, http.StatusText(statusErr.StatusCode)),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Skip the generated table of contents.
+	page, err := parseHeadingHTML(string(body), "#dw__toc")
+	if err != nil {
+		return nil, err
+	}
+	if page.Title == "" {
+		page.Title = id
+	}
+	return page, nil
+}
+
+// GetSections keeps the sections of the page specified by path selected by
+// the filter. DokuWiki renders whole pages only, so the page is always
+// fetched in full.
+func (s *DokuWikiScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
+
+// htmlHeading is a heading of a rendered page along with the paragraph
+// text following it, up to the next heading.
+type htmlHeading struct {
+	Level   int
+	Text    string
+	Content string
+}
+
+// paragraphText collects the text of every paragraph in the selection,
+// including paragraphs nested within it.
+func paragraphText(sel *goquery.Selection) string {
+	var contentBuilder strings.Builder
+	sel.Each(func(_ int, s *goquery.Selection) {
+		if s.Is("p") {
+			contentBuilder.WriteString(s.Text())
+			return
+		}
+		s.Find("p").Each(func(_ int, p *goquery.Selection) {
+			contentBuilder.WriteString(p.Text())
+		})
+	})
+	return contentBuilder.String()
+}
+
+// parseHeadingHTML parses a rendered HTML page structured by plain heading
+// elements, such as those produced by DokuWiki or Wiki.js, into sections.
+// Elements matching ignore, such as a generated table of contents, are
+// removed first. Pages usually open with a single first level heading
+// holding the page title, in which case it becomes the page title, its text
+// the introduction, and the next heading level forms the sections. Deeper
+// headings are folded into their parent section.
+func parseHeadingHTML(html string, ignore string) (*Page, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+	if ignore != "" {
+		doc.Find(ignore).Remove()
+	}
+	var headings []htmlHeading
+	doc.Find(htmlHeadings).Each(func(_ int, h *goquery.Selection) {
+		headings = append(headings, htmlHeading{
+			Level:   int(goquery.NodeName(h)[1] - '0'),
+			Text:    strings.TrimSpace(h.Text()),
+			Content: paragraphText(h.NextUntil(htmlHeadings)),
+		})
+	})
+	var introBuilder strings.Builder
+	doc.Find("body").Children().EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if s.Is(htmlHeadings) {
+			return false
+		}
+		introBuilder.WriteString(paragraphText(s))
+		return true
+	})
+	return foldHeadings(introBuilder.String(), headings), nil
+}
+
+// foldHeadings builds a page from a flat list of headings. A lone heading at
+// the highest level which opens the page, with no text before it, is treated
+// as the page title, and the highest remaining level forms the sections.
+func foldHeadings(intro string, headings []htmlHeading) *Page {
+	page := &Page{}
+	if len(headings) > 0 {
+		top, count := headings[0].Level, 0
+		for _, h := range headings {
+			top = min(top, h.Level)
+		}
+		for _, h := range headings {
+			if h.Level == top {
+				count++
+			}
+		}
+		if count == 1 && headings[0].Level == top && strings.TrimSpace(intro) == "" {
+			page.Title = headings[0].Text
+			intro += headings[0].Content
+			headings = headings[1:]
+		}
+	}
+	level := 7
+	for _, h := range headings {
+		level = min(level, h.Level)
+	}
+	page.Sections = []*Section{{Heading: "Introduction", Index: 0, Content: intro}}
+	for _, h := range headings {
+		current := page.Sections[len(page.Sections)-1]
+		if h.Level > level {
+			current.Content += h.Content
+			continue
+		}
+		page.Sections = append(page.Sections, &Section{
+			Heading: h.Text,
+			Index:   len(page.Sections),
+			Content: h.Content,
+		})
+	}
+	return page
+}
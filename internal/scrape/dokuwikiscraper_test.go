@@ -0,0 +1,62 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testDokuWikiHTML = `
+<div id="dw__toc"><h3>Table of Contents</h3><ul><li>Install</li></ul></div>
+<h1 class="sectionedit1" id="deployment">Deployment</h1>
+<div class="level1"><p>How we deploy.</p></div>
+<h2 class="sectionedit2" id="install">Install</h2>
+<div class="level2"><p>Run the installer.</p></div>
+<h3 class="sectionedit3" id="linux">Linux</h3>
+<div class="level3"><p>Use apt.</p><ul><li>not a paragraph</li></ul></div>
+<h2 class="sectionedit4" id="rollback">Rollback</h2>
+<div class="level2"><p>Revert.</p></div>`
+
+func TestDokuWikiScraper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("do") != "export_xhtmlbody" {
+			t.Errorf("Unexpected action: %s", query.Get("do"))
+		}
+		if query.Get("id") != "ops:deployment_guide" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, testDokuWikiHTML)
+	}))
+	defer server.Close()
+	scraper := &scrape.DokuWikiScraper{BaseURL: server.URL + "/doku.php"}
+
+	// Test 1: Valid page, with the id normalized
+	page, err := scraper.GetPage("ops:Deployment Guide")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "How we deploy."},
+		{Heading: "Install", Index: 1, Content: "Run the installer.Use apt."},
+		{Heading: "Rollback", Index: 2, Content: "Revert."},
+	}
+	if page.Title != "Deployment" || len(page.Sections) != len(want) {
+		t.Fatalf("Unexpected page: %+v", page)
+	}
+	for i, s := range page.Sections {
+		if *s != want[i] {
+			t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, want[i])
+		}
+	}
+
+	// Test 2: Missing page
+	_, err = scraper.GetPage("missing")
+	if _, ok := err.(*scrape.DokuWikiError); !ok {
+		t.Errorf("Expected a DokuWikiError, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package scrape
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+// Markup added to page content by Fandom and the templates common on its
+// wikis, none of which is article text. Portable infoboxes are parsed
+// separately.
+const fandomIgnore = "aside.portable-infobox, .navbox, .toc, .mw-editsection, sup.reference, " +
+	".mw-references-wrap, .noprint, .wikia-gallery, .gallery, figure, .article-thumb, " +
+	".notice, .mbox, .dablink, .hatnote, .quote, #incontent_player, .ad-slot, script, style"
+
+// Wraps methods for retrieving and parsing pages on a Fandom wiki. Fandom
+// runs MediaWiki, so every MediaWikiScraper capability is available, but
+// page content is cleaned of Fandom specific markup and full pages carry the
+// fields of their portable infobox. BaseURL is the wiki's api.php endpoint,
+// such as "https://minecraft.fandom.com/api.php".
+type FandomScraper struct {
+	MediaWikiScraper
+}
+
+// NewFandomScraper returns a FandomScraper for the wiki whose API is found
+// at baseURL, caching responses in c when it is not nil.
+func NewFandomScraper(baseURL string, c *cache.Cache) *FandomScraper {
+	return &FandomScraper{MediaWikiScraper{BaseURL: baseURL, Cache: c, Ignore: fandomIgnore}}
+}
+
+// GetPage fetches and parses the page specified by path, including the
+// fields of its first portable infobox. The infobox image, if any, becomes
+// the page Thumbnail.
+//
+// Can error when:
+//   - page fetch fails.
+//   - section parsing fails
+func (s *FandomScraper) GetPage(path string) (*Page, error) {
+	response, err := s.fetchPage(path)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := response.ParseSections()
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{
+		Title:    response.Parse.Title,
+		Revision: response.Parse.RevID,
+		Sections: sections,
+	}
+	if err := parsePortableInfobox(response.Parse.Text.Value, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetSections returns only the sections of the page specified by path that
+// are selected by the filter. Infobox fields are only included when the
+// filter is empty and the whole page is fetched.
+func (s *FandomScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	if filter.IsEmpty() {
+		return s.GetPage(path)
+	}
+	return s.MediaWikiScraper.GetSections(path, filter)
+}
+
+// parsePortableInfobox reads the fields of the first portable infobox in the
+// HTML of a page into page.Infobox, and its image into page.Thumbnail. Plain
+// fields, horizontal groups and smart groups are all read; fields within a
+// titled group carry the group's heading.
+func parsePortableInfobox(html string, page *Page) error {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return err
+	}
+	infobox := doc.Find("aside.portable-infobox").First()
+	if infobox.Length() == 0 {
+		return nil
+	}
+	infobox.Find("sup.reference").Remove()
+	if src, ok := infobox.Find(".pi-image img").First().Attr("src"); ok && page.Thumbnail == "" {
+		page.Thumbnail = src
+	}
+	group := func(sel *goquery.Selection) string {
+		return infoboxText(sel.Closest("section.pi-group").Find(".pi-header").First())
+	}
+	add := func(groupName string, label string, value string) {
+		if value == "" {
+			return
+		}
+		page.Infobox = append(page.Infobox, &InfoboxField{Group: groupName, Label: label, Value: value})
+	}
+	infobox.Find(".pi-data, .pi-horizontal-group, .pi-smart-group").Each(func(_ int, item *goquery.Selection) {
+		switch {
+		case item.HasClass("pi-data"):
+			label := infoboxText(item.Find(".pi-data-label"))
+			if label == "" {
+				label = item.AttrOr("data-source", "")
+			}
+			add(group(item), label, infoboxText(item.Find(".pi-data-value")))
+		case item.HasClass("pi-horizontal-group"):
+			labels := item.Find("th.pi-data-label, th.pi-horizontal-group-item")
+			item.Find("td.pi-data-value, td.pi-horizontal-group-item").Each(func(i int, value *goquery.Selection) {
+				label := infoboxText(labels.Eq(i))
+				if label == "" {
+					label = value.AttrOr("data-source", "")
+				}
+				add(group(item), label, infoboxText(value))
+			})
+		default:
+			labels := item.Find(".pi-smart-data-label")
+			item.Find(".pi-smart-data-value").Each(func(i int, value *goquery.Selection) {
+				label := infoboxText(labels.Eq(i))
+				if label == "" {
+					label = value.AttrOr("data-source", "")
+				}
+				add(group(item), label, infoboxText(value))
+			})
+		}
+	})
+	return nil
+}
+
+// infoboxText returns the text of an infobox element, with line breaks
+// separating multiple values read as commas.
+func infoboxText(sel *goquery.Selection) string {
+	sel.Find("br").ReplaceWithHtml("\n")
+	var parts []string
+	for _, line := range strings.Split(sel.Text(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
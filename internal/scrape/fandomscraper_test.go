@@ -0,0 +1,86 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testFandomHTML = `<div class="mw-parser-output">
+<aside class="portable-infobox pi-background">
+<h2 class="pi-item pi-title" data-source="title">Creeper</h2>
+<figure class="pi-item pi-image" data-source="image"><a href="/wiki/File:Creeper.png"><img src="https://static.wikia.nocookie.net/creeper.png"></a></figure>
+<div class="pi-item pi-data" data-source="health"><h3 class="pi-data-label">Health points</h3><div class="pi-data-value">20<sup class="reference">[1]</sup></div></div>
+<section class="pi-item pi-group"><h2 class="pi-item pi-header">Behavior</h2>
+<div class="pi-item pi-data" data-source="spawn"><h3 class="pi-data-label">Spawn</h3><div class="pi-data-value">Overworld<br>Caves</div></div>
+<table class="pi-horizontal-group"><thead><tr><th class="pi-horizontal-group-item pi-data-label" data-source="easy">Easy</th><th class="pi-horizontal-group-item pi-data-label" data-source="hard">Hard</th></tr></thead>
+<tbody><tr><td class="pi-horizontal-group-item pi-data-value" data-source="easy">22</td><td class="pi-horizontal-group-item pi-data-value" data-source="hard">49</td></tr></tbody></table>
+</section>
+<section class="pi-item pi-smart-group"><section class="pi-smart-group-head"><h3 class="pi-smart-data-label">ID</h3></section><section class="pi-smart-group-body"><div class="pi-smart-data-value" data-source="id">creeper</div></section></section>
+</aside>
+<div class="dablink"><p>For the mod, see Creeper (mod).</p></div>
+<p>A creeper is a hostile mob.<sup class="reference">[2]</sup></p>
+<h2><span class="mw-headline" id="Behavior">Behavior</span><span class="mw-editsection">[edit]</span></h2>
+<p>Creepers explode.</p>
+<table class="navbox"><tr><td><p>Mobs navigation</p></td></tr></table>
+</div>`
+
+func TestFandomScraper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("page") != "Creeper" {
+			fmt.Fprint(w, `{"error":{"code":"missingtitle","info":"The page you specified doesn't exist."}}`)
+			return
+		}
+		jsoniter.NewEncoder(w).Encode(map[string]any{
+			"parse": map[string]any{"title": "Creeper", "text": map[string]any{"*": testFandomHTML}},
+		})
+	}))
+	defer server.Close()
+	scraper := scrape.NewFandomScraper(server.URL+"/api.php", nil)
+
+	// Test 1: Page content without Fandom markup
+	page, err := scraper.GetPage("Creeper")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "A creeper is a hostile mob."},
+		{Heading: "Behavior", Index: 1, Content: "Creepers explode."},
+	}
+	if len(page.Sections) != len(want) {
+		t.Fatalf("Unexpected sections: %+v", page.Sections)
+	}
+	for i, s := range page.Sections {
+		if *s != want[i] {
+			t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, want[i])
+		}
+	}
+
+	// Test 2: Portable infobox fields and image
+	wantFields := []scrape.InfoboxField{
+		{Label: "Health points", Value: "20"},
+		{Group: "Behavior", Label: "Spawn", Value: "Overworld, Caves"},
+		{Group: "Behavior", Label: "Easy", Value: "22"},
+		{Group: "Behavior", Label: "Hard", Value: "49"},
+		{Label: "ID", Value: "creeper"},
+	}
+	if page.Thumbnail != "https://static.wikia.nocookie.net/creeper.png" || len(page.Infobox) != len(wantFields) {
+		t.Fatalf("Unexpected infobox: %v, %s", page.Infobox, page.Thumbnail)
+	}
+	for i, f := range page.Infobox {
+		if *f != wantFields[i] {
+			t.Errorf("Infobox field mismatch at index %d. Got: %+v, Want: %+v", i, *f, wantFields[i])
+		}
+	}
+
+	// Test 3: Missing page
+	_, err = scraper.GetPage("Zombie")
+	if _, ok := err.(*scrape.MediaWikiAPIError); !ok {
+		t.Errorf("Expected a MediaWikiAPIError, got %v", err)
+	}
+}
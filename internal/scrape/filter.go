@@ -0,0 +1,173 @@
+package scrape
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+// SectionFilter selects sections of a page by heading, index or regular
+// expression, and can exclude sections matching a set of regular expressions.
+// It is backend agnostic and operates on already parsed Sections, so every
+// Scraper implementation can share the same selection rules.
+//
+// A section is kept when it matches at least one of the include criteria
+// (or no include criteria are given) and none of the Exclude patterns.
+type SectionFilter struct {
+	Headings []string
+	Indices  []int
+	Patterns []*regexp.Regexp
+	Exclude  []*regexp.Regexp
+}
+
+// SectionNotFoundError indicates that no section of a page matched
+// the provided SectionFilter.
+type SectionNotFoundError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted SectionNotFoundError including code and
+// additional information.
+func (e *SectionNotFoundError) Error() string {
+	return fmt.Sprintf("SectionNotFoundError: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// NewSectionFilter builds a SectionFilter from raw command line values.
+// Patterns and exclusions are compiled as case-insensitive regular expressions.
+//
+// Can error when:
+//   - A pattern or exclusion is not a valid regular expression
+func NewSectionFilter(headings []string, indices []int, patterns []string, exclude []string) (*SectionFilter, error) {
+	filter := &SectionFilter{
+		Headings: headings,
+		Indices:  indices,
+	}
+	var err error
+	filter.Patterns, err = util.CompilePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	filter.Exclude, err = util.CompilePatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter would keep every section of a page.
+// A nil filter is empty.
+func (f *SectionFilter) IsEmpty() bool {
+	return f == nil || (len(f.Headings) == 0 && len(f.Indices) == 0 && len(f.Patterns) == 0 && len(f.Exclude) == 0)
+}
+
+// hasIncludes reports whether any include criteria have been set.
+func (f *SectionFilter) hasIncludes() bool {
+	return len(f.Headings) > 0 || len(f.Indices) > 0 || len(f.Patterns) > 0
+}
+
+// selectsOne reports whether the filter can select at most one section:
+// a single heading or index, and no pattern.
+func (f *SectionFilter) selectsOne() bool {
+	return f != nil && len(f.Headings)+len(f.Indices) == 1 && len(f.Patterns) == 0
+}
+
+// Match reports whether a section is selected by the filter. Headings are
+// compared case-insensitively after trimming surrounding whitespace.
+func (f *SectionFilter) Match(s *Section) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	for _, re := range f.Exclude {
+		if re.MatchString(s.Heading) {
+			return false
+		}
+	}
+	if !f.hasIncludes() {
+		return true
+	}
+	for _, heading := range f.Headings {
+		if util.TrimLower(heading) == util.TrimLower(s.Heading) {
+			return true
+		}
+	}
+	for _, index := range f.Indices {
+		if index == s.Index {
+			return true
+		}
+	}
+	for _, re := range f.Patterns {
+		if re.MatchString(s.Heading) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the sections matched by the filter, preserving their order.
+//
+// Can error when:
+//   - No section matches the filter, the title is used to build
+//     the SectionNotFoundError
+func (f *SectionFilter) Apply(title string, sections []*Section) ([]*Section, error) {
+	var matched []*Section
+	for _, s := range sections {
+		if f.Match(s) {
+			matched = append(matched, s)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, &SectionNotFoundError{
+			Code: "sectionnotfound",
+			Info: fmt.Sprintf("No section matching %s was found on page %s", f, title),
+		}
+	}
+	return matched, nil
+}
+
+// String returns a short human readable description of the filter.
+func (f *SectionFilter) String() string {
+	if f.IsEmpty() {
+		return "(all sections)"
+	}
+	var parts []string
+	for _, heading := range f.Headings {
+		parts = append(parts, fmt.Sprintf("heading %q", heading))
+	}
+	for _, index := range f.Indices {
+		parts = append(parts, "index "+strconv.Itoa(index))
+	}
+	for _, re := range f.Patterns {
+		parts = append(parts, fmt.Sprintf("pattern %q", strings.TrimPrefix(re.String(), "(?i)")))
+	}
+	for _, re := range f.Exclude {
+		parts = append(parts, fmt.Sprintf("excluding %q", strings.TrimPrefix(re.String(), "(?i)")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// getFilteredPage fetches the whole page specified by path with the scraper
+// and keeps only the sections selected by the filter. It implements
+// GetSections for backends which cannot fetch sections individually.
+func getFilteredPage(scraper Scraper, path string, filter *SectionFilter) (*Page, error) {
+	page, err := scraper.GetPage(path)
+	if err != nil {
+		return nil, err
+	}
+	return filterPage(page, filter)
+}
+
+// filterPage replaces the sections of a page with those selected by the
+// filter, for scrapers which must retrieve the whole page regardless.
+func filterPage(page *Page, filter *SectionFilter) (*Page, error) {
+	sections, err := filter.Apply(page.Title, page.Sections)
+	if err != nil {
+		return nil, err
+	}
+	page.Sections = sections
+	page.Filter = filter
+	return page, nil
+}
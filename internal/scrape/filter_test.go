@@ -0,0 +1,90 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+func testSections() []*scrape.Section {
+	return []*scrape.Section{
+		{Heading: "Introduction", Index: 0},
+		{Heading: "History", Index: 1},
+		{Heading: "Gameplay", Index: 2},
+		{Heading: "See also", Index: 3},
+		{Heading: "References", Index: 4},
+		{Heading: "External links", Index: 5},
+	}
+}
+
+func headings(sections []*scrape.Section) []string {
+	var got []string
+	for _, s := range sections {
+		got = append(got, s.Heading)
+	}
+	return got
+}
+
+func TestSectionFilterApply(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Headings []string
+		Indices  []int
+		Patterns []string
+		Exclude  []string
+		Want     []string
+	}{
+		{"empty", nil, nil, nil, nil, []string{"Introduction", "History", "Gameplay", "See also", "References", "External links"}},
+		{"headings", []string{" HISTORY ", "gameplay"}, nil, nil, nil, []string{"History", "Gameplay"}},
+		{"indices", nil, []int{0, 2}, nil, nil, []string{"Introduction", "Gameplay"}},
+		{"pattern", nil, nil, []string{"^(see|ext)"}, nil, []string{"See also", "External links"}},
+		{"exclude", nil, nil, nil, []string{"References|See also|External links"}, []string{"Introduction", "History", "Gameplay"}},
+		{"include and exclude", []string{"History"}, []int{4}, nil, []string{"references"}, []string{"History"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			filter, err := scrape.NewSectionFilter(tc.Headings, tc.Indices, tc.Patterns, tc.Exclude)
+			if err != nil {
+				t.Fatalf("Failed to build filter: %v", err)
+			}
+			got, err := filter.Apply("Test", testSections())
+			if err != nil {
+				t.Fatalf("Unexpected error applying filter: %v", err)
+			}
+			gotHeadings := headings(got)
+			if len(gotHeadings) != len(tc.Want) {
+				t.Fatalf("Section mismatch. Got: %v, Want: %v", gotHeadings, tc.Want)
+			}
+			for i := range tc.Want {
+				if gotHeadings[i] != tc.Want[i] {
+					t.Errorf("Section mismatch at index %d. Got: %s, Want: %s", i, gotHeadings[i], tc.Want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSectionFilterErrors(t *testing.T) {
+	// Test 1: Invalid regex
+	_, err := scrape.NewSectionFilter(nil, nil, []string{"("}, nil)
+	if err == nil {
+		t.Error("Expected an error for an invalid pattern, but got nil")
+	}
+
+	// Test 2: No matching section
+	filter, err := scrape.NewSectionFilter([]string{"Trivia"}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	_, err = filter.Apply("Test", testSections())
+	if _, ok := err.(*scrape.SectionNotFoundError); !ok {
+		t.Errorf("Expected a SectionNotFoundError, got %v", err)
+	}
+
+	// Test 3: Nil filter keeps everything
+	var nilFilter *scrape.SectionFilter
+	got, err := nilFilter.Apply("Test", testSections())
+	if err != nil || len(got) != len(testSections()) {
+		t.Errorf("Expected nil filter to keep all sections, got %d sections and error %v", len(got), err)
+	}
+}
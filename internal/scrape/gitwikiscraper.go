@@ -0,0 +1,145 @@
+package scrape
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Page file extensions read by GitWikiScraper, mapped to the parser for
+// that markup.
+var gitWikiFormats = map[string]func(string) *Page{
+	".md":        ParseMarkdown,
+	".markdown":  ParseMarkdown,
+	".mdown":     ParseMarkdown,
+	".mkd":       ParseMarkdown,
+	".mkdn":      ParseMarkdown,
+	".asciidoc":  ParseAsciiDoc,
+	".adoc":      ParseAsciiDoc,
+	".asc":       ParseAsciiDoc,
+	".mediawiki": parseWikitextPage,
+	".wiki":      parseWikitextPage,
+}
+
+// Wraps methods for retrieving and parsing pages from a local clone of a
+// Git backed wiki, such as a GitHub or GitLab project wiki or any wiki
+// served by Gollum. Path is the root of the clone's working tree. Pages
+// are read from Markdown, AsciiDoc and MediaWiki files.
+type GitWikiScraper struct {
+	Path string
+}
+
+// GitWikiError indicates that a page could not be found in a Git wiki.
+type GitWikiError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted Git wiki error including code and
+// additional information.
+func (e *GitWikiError) Error() string {
+	return fmt.Sprintf("Git wiki error: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// gitWikiName canonicalizes a page name or file name (without its
+// extension) the way Gollum does when matching them: spaces and dashes
+// are interchangeable and case is ignored.
+func gitWikiName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "-"))
+}
+
+// gitWikiTitle converts a page file name into a page title.
+func gitWikiTitle(file string) string {
+	name := filepath.Base(file)
+	return strings.ReplaceAll(strings.TrimSuffix(name, filepath.Ext(name)), "-", " ")
+}
+
+// parseWikitextPage parses a MediaWiki markup page file.
+func parseWikitextPage(text string) *Page {
+	return &Page{Sections: ParseWikitextSections(text)}
+}
+
+// findPage returns the file holding the page named by path. Names
+// containing a slash are matched against the full path of each page
+// relative to the wiki root, other names against the file name alone, in
+// which case the page closest to the root wins. Files starting with an
+// underscore, such as _Sidebar.md, are not pages.
+func (s *GitWikiScraper) findPage(path string) (string, error) {
+	name, err := url.QueryUnescape(path)
+	if err != nil {
+		return "", err
+	}
+	name = gitWikiName(strings.Trim(name, "/"))
+	if name == "" {
+		name = "home"
+	}
+	found, foundDepth := "", 0
+	err = filepath.WalkDir(s.Path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(file))
+		if _, ok := gitWikiFormats[ext]; !ok || strings.HasPrefix(d.Name(), "_") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Path, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		candidate := rel
+		if !strings.Contains(name, "/") {
+			candidate = rel[strings.LastIndex(rel, "/")+1:]
+		}
+		depth := strings.Count(rel, "/")
+		if gitWikiName(candidate) == name && (found == "" || depth < foundDepth) {
+			found, foundDepth = file, depth
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", &GitWikiError{
+			Code: "pagenotfound",
+			Info: fmt.Sprintf("No page named %s was found in %s", path, s.Path),
+		}
+	}
+	return found, nil
+}
+
+// GetPage reads the page file named by path and parses it into sections.
+// The page title is taken from the file name, with dashes read as spaces.
+//
+// Can error when:
+//   - The wiki cannot be read
+//   - There is no page with the given name
+func (s *GitWikiScraper) GetPage(path string) (*Page, error) {
+	file, err := s.findPage(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	page := gitWikiFormats[strings.ToLower(filepath.Ext(file))](string(content))
+	page.Title = gitWikiTitle(file)
+	return page, nil
+}
+
+// GetSections keeps the sections of the page specified by path selected by
+// the filter, after reading the whole page file.
+func (s *GitWikiScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
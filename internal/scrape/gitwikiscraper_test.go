@@ -0,0 +1,76 @@
+package scrape_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+func TestGitWikiScraper(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":                 "ref: refs/heads/master\n",
+		"Home.md":                   "Welcome to the [[project wiki|Home]].\n",
+		"_Sidebar.md":               "Not a page.\n",
+		"Getting-Started.md":        "Install it first.\n## Usage\nRun `tool`.\n",
+		"guides/Getting-Started.md": "A deeper page.\n",
+		"guides/Deploying.adoc":     "= Deploying\n:toc:\n\nShip it with <<rollback,care>>.\n\n== Rollback\n----\nrollback.sh\n----\nUse the https://example.com[runbook].\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scraper := &scrape.GitWikiScraper{Path: dir}
+
+	// Test 1: Names match file names with spaces read as dashes, ignoring case, closest to the root
+	page, err := scraper.GetPage("getting started")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if page.Title != "Getting Started" || len(page.Sections) != 2 || page.Sections[0].Content != "Install it first.\n" || page.Sections[1].Content != "Run tool.\n" {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+
+	// Test 2: Names with a directory match the full path
+	page, err = scraper.GetPage("guides/Getting-Started")
+	if err != nil || page.Sections[0].Content != "A deeper page.\n" {
+		t.Errorf("Unexpected page: %+v, %v", page, err)
+	}
+
+	// Test 3: AsciiDoc page
+	page, err = scraper.GetPage("Deploying")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "Ship it with care.\n"},
+		{Heading: "Rollback", Index: 1, Content: "Use the runbook.\n"},
+	}
+	if len(page.Sections) != len(want) {
+		t.Fatalf("Unexpected sections: %+v", page.Sections)
+	}
+	for i, s := range page.Sections {
+		if *s != want[i] {
+			t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, want[i])
+		}
+	}
+
+	// Test 4: Empty name reads the home page, wiki links are reduced to their text
+	page, err = scraper.GetPage("")
+	if err != nil || page.Sections[0].Content != "Welcome to the project wiki.\n" {
+		t.Errorf("Unexpected page: %+v, %v", page, err)
+	}
+
+	// Test 5: Files starting with an underscore are not pages
+	_, err = scraper.GetPage("_Sidebar")
+	if _, ok := err.(*scrape.GitWikiError); !ok {
+		t.Errorf("Expected a GitWikiError, got %v", err)
+	}
+}
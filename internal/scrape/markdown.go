@@ -0,0 +1,137 @@
+package scrape
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownHeading  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?\s*#*\s*$`)
+	markdownSetext   = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	markdownFence    = regexp.MustCompile("^ {0,3}(```|~~~)")
+	markdownRule     = regexp.MustCompile(`^ {0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	markdownListItem = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s`)
+	markdownImage    = regexp.MustCompile(`!\[[^\]]*\](\([^)]*\)|\[[^\]]*\])`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	markdownAutoLink = regexp.MustCompile(`<((?:https?|mailto):[^>]+)>`)
+	markdownCode     = regexp.MustCompile("`+([^`]*)`+")
+	markdownStrong   = regexp.MustCompile(`\*\*|__|~~`)
+	markdownEmphasis = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+	markdownRefDef   = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s`)
+	// Gollum wiki links, [[Page Name]] or [[Link Text|Page Name]]
+	markdownWikiLink = regexp.MustCompile(`\[\[([^\]|]*)(?:\|[^\]]*)?\]\]`)
+)
+
+// ParseMarkdown splits a Markdown document into a Page, following the same
+// rules as rendered HTML pages: a lone opening top level heading becomes the
+// page title, the next heading level forms the sections, and deeper headings
+// are folded into their parent section. Only paragraph text is kept; code
+// blocks, lists, tables, quotes and images are discarded and links, including
+// Gollum style [[wiki links]], are reduced to their text. Each paragraph is
+// terminated by a newline.
+func ParseMarkdown(text string) *Page {
+	var intro string
+	var headings []htmlHeading
+	var body strings.Builder
+	flush := func() {
+		content := markdownToText(body.String())
+		body.Reset()
+		if len(headings) == 0 {
+			intro = content
+			return
+		}
+		headings[len(headings)-1].Content = content
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			body.WriteByte('\n')
+			continue
+		}
+		level, heading := 0, ""
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			level, heading = len(m[1]), m[2]
+		} else if i+1 < len(lines) && isMarkdownParagraph(line) && markdownSetext.MatchString(lines[i+1]) {
+			level, heading = 1, line
+			if strings.TrimSpace(lines[i+1])[0] == '-' {
+				level = 2
+			}
+			i++
+		}
+		if level > 0 {
+			flush()
+			headings = append(headings, htmlHeading{
+				Level: level,
+				Text:  strings.TrimSpace(markdownInline(heading)),
+			})
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+	return foldHeadings(intro, headings)
+}
+
+// isMarkdownParagraph reports whether line holds paragraph text rather than
+// some other block, such as a list item or a table row.
+func isMarkdownParagraph(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "", strings.HasPrefix(line, "    "), strings.HasPrefix(line, "\t"):
+		return false
+	case strings.ContainsAny(trimmed[:1], ">|<"):
+		return false
+	case markdownRule.MatchString(line), markdownListItem.MatchString(line), markdownRefDef.MatchString(line):
+		return false
+	}
+	return true
+}
+
+// markdownInline strips inline Markdown formatting from a line of text.
+func markdownInline(text string) string {
+	text = markdownWikiLink.ReplaceAllString(text, "$1")
+	text = markdownImage.ReplaceAllString(text, "")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownAutoLink.ReplaceAllString(text, "$1")
+	text = markdownCode.ReplaceAllString(text, "$1")
+	text = markdownStrong.ReplaceAllString(text, "")
+	text = markdownEmphasis.ReplaceAllString(text, "$1$2")
+	text = wikitextTag.ReplaceAllString(text, "")
+	return html.UnescapeString(text)
+}
+
+// markdownToText reduces a fragment of Markdown to its paragraph text.
+// Each paragraph is terminated by a newline.
+func markdownToText(text string) string {
+	var out strings.Builder
+	var paragraph []string
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString(strings.Join(paragraph, " "))
+			out.WriteByte('\n')
+			paragraph = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if !isMarkdownParagraph(line) {
+			endParagraph()
+			continue
+		}
+		if line := strings.TrimSpace(markdownInline(line)); line != "" {
+			paragraph = append(paragraph, line)
+		}
+	}
+	endParagraph()
+	return out.String()
+}
@@ -0,0 +1,46 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testMarkdown = "# Deployment\n" +
+	"How we **deploy** to [production](https://example.com/prod).\n" +
+	"It takes `five` minutes.\n" +
+	"\n" +
+	"Install\n" +
+	"-------\n" +
+	"Run the _installer_. ![diagram](install.png)\n" +
+	"\n" +
+	"- not a paragraph\n" +
+	"```sh\n" +
+	"# not a heading\n" +
+	"```\n" +
+	"### Linux ###\n" +
+	"Use apt &amp; dpkg.\n" +
+	"## Rollback\n" +
+	"| a | b |\n" +
+	"> quoted\n" +
+	"Revert.\n"
+
+func TestParseMarkdown(t *testing.T) {
+	page := scrape.ParseMarkdown(testMarkdown)
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "How we deploy to production. It takes five minutes.\n"},
+		{Heading: "Install", Index: 1, Content: "Run the installer.\nUse apt & dpkg.\n"},
+		{Heading: "Rollback", Index: 2, Content: "Revert.\n"},
+	}
+	if page.Title != "Deployment" {
+		t.Errorf("Title mismatch. Got: %s, Want: Deployment", page.Title)
+	}
+	if len(page.Sections) != len(want) {
+		t.Fatalf("Section count mismatch. Got: %d, Want: %d", len(page.Sections), len(want))
+	}
+	for i, s := range page.Sections {
+		if *s != want[i] {
+			t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, want[i])
+		}
+	}
+}
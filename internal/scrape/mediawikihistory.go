@@ -0,0 +1,245 @@
+package scrape
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+// RevisionPath returns the path of a revision, which GetPage and
+// GetSections request by id through Special:Redirect.
+func (s *MediaWikiScraper) RevisionPath(revision int) string {
+	return util.RevisionPage(revision)
+}
+
+// GetRevisionAt returns the id of the revision of the page specified by path
+// that was current at the given time: the latest revision made at or before
+// it. Redirects are followed.
+//
+// Can error when:
+//   - The query fails
+//   - The page does not exist, or did not exist yet at that time
+func (s *MediaWikiScraper) GetRevisionAt(path string, at time.Time) (int, error) {
+	title, err := url.QueryUnescape(path)
+	if err != nil {
+		return 0, err
+	}
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("prop", "revisions")
+	params.Set("rvprop", "ids|timestamp")
+	params.Set("rvlimit", "1")
+	params.Set("rvdir", "older")
+	params.Set("rvstart", at.UTC().Format(time.RFC3339))
+	params.Set("redirects", "1")
+	params.Set("titles", title)
+	var response mediaWikiRevisionsResponse
+	if err := s.liveQuery(params, &response); err != nil {
+		return 0, err
+	}
+	if len(response.Query.Pages) == 0 || response.Query.Pages[0].Missing {
+		return 0, &MediaWikiAPIError{
+			Code: "missingtitle",
+			Info: fmt.Sprintf("The page %s does not exist", title),
+		}
+	}
+	revisions := response.Query.Pages[0].Revisions
+	if len(revisions) == 0 {
+		return 0, &MediaWikiAPIError{
+			Code: "norevision",
+			Info: fmt.Sprintf("The page %s has no revision at or before %s", title, at.UTC().Format(time.RFC3339)),
+		}
+	}
+	return revisions[0].RevID, nil
+}
+
+// GetHistory lists the revisions of the page specified by path, newest first,
+// following continuation until every revision selected by opts is listed.
+// Redirects are followed. The history is never served from the cache, as
+// every edit changes it.
+//
+// Can error when:
+//   - The query fails
+//   - The page does not exist
+func (s *MediaWikiScraper) GetHistory(path string, opts *HistoryOptions) ([]*Revision, error) {
+	title, err := url.QueryUnescape(path)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &HistoryOptions{}
+	}
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("prop", "revisions")
+	params.Set("rvprop", "ids|timestamp|user|comment|size|flags|tags")
+	params.Set("rvdir", "older")
+	params.Set("redirects", "1")
+	params.Set("titles", title)
+	if !opts.Until.IsZero() {
+		params.Set("rvstart", opts.Until.UTC().Format(time.RFC3339))
+	}
+	if !opts.Since.IsZero() {
+		params.Set("rvend", opts.Since.UTC().Format(time.RFC3339))
+	}
+
+	var revisions []*Revision
+	parents := map[int]int{}
+	for {
+		limit := mediaWikiMaxLimit
+		if opts.Limit > 0 {
+			limit = min(limit, opts.Limit-len(revisions))
+		}
+		params.Set("rvlimit", strconv.Itoa(limit))
+		var response mediaWikiRevisionsResponse
+		if err := s.liveQuery(params, &response); err != nil {
+			return nil, err
+		}
+		if len(response.Query.Pages) == 0 || response.Query.Pages[0].Missing {
+			return nil, &MediaWikiAPIError{
+				Code: "missingtitle",
+				Info: fmt.Sprintf("The page %s does not exist", title),
+			}
+		}
+		for _, r := range response.Query.Pages[0].Revisions {
+			revisions = append(revisions, &Revision{
+				ID:        r.RevID,
+				Timestamp: r.Timestamp,
+				User:      r.User,
+				Comment:   r.Comment,
+				Size:      r.Size,
+				Minor:     r.Minor,
+				Tags:      r.Tags,
+			})
+			parents[r.RevID] = r.ParentID
+		}
+		if len(response.Continue) == 0 || (opts.Limit > 0 && len(revisions) >= opts.Limit) {
+			break
+		}
+		response.Continue.apply(params)
+	}
+
+	// Each revision's parent is the next one listed, except for the oldest,
+	// whose parent may lie outside the selected range.
+	for i, r := range revisions {
+		switch {
+		case parents[r.ID] == 0:
+			r.SizeDelta = r.Size
+		case i+1 < len(revisions) && revisions[i+1].ID == parents[r.ID]:
+			r.SizeDelta = r.Size - revisions[i+1].Size
+		default:
+			size, err := s.revisionSize(parents[r.ID])
+			if err != nil {
+				return nil, err
+			}
+			r.SizeDelta = r.Size - size
+		}
+	}
+	return revisions, nil
+}
+
+// revisionSize returns the size in bytes of the page at the given revision.
+// Past revisions do not change, so the size may be served from the cache.
+func (s *MediaWikiScraper) revisionSize(revision int) (int, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("prop", "revisions")
+	params.Set("rvprop", "ids|size")
+	params.Set("revids", strconv.Itoa(revision))
+	var response mediaWikiRevisionsResponse
+	if err := s.query(params, &response); err != nil {
+		return 0, err
+	}
+	for _, p := range response.Query.Pages {
+		for _, r := range p.Revisions {
+			if r.RevID == revision {
+				return r.Size, nil
+			}
+		}
+	}
+	// The parent revision was deleted; count the whole page as the change.
+	return 0, nil
+}
+
+// GetLatestRevisions looks up the latest revision of every page in paths,
+// up to mediaWikiMaxTitles per request. Redirects are followed, so pages
+// which were moved are reported under their new title.
+//
+// Can error when:
+//   - The query fails
+func (s *MediaWikiScraper) GetLatestRevisions(paths []string) ([]*PageState, error) {
+	var states []*PageState
+	for start := 0; start < len(paths); start += mediaWikiMaxTitles {
+		batch, err := s.latestRevisions(paths[start:min(start+mediaWikiMaxTitles, len(paths))])
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, batch...)
+	}
+	return states, nil
+}
+
+// latestRevisions looks up the latest revision of at most
+// mediaWikiMaxTitles pages in a single query, never served from the cache.
+func (s *MediaWikiScraper) latestRevisions(paths []string) ([]*PageState, error) {
+	titles := make([]string, len(paths))
+	for i, path := range paths {
+		title, err := url.QueryUnescape(path)
+		if err != nil {
+			return nil, err
+		}
+		titles[i] = title
+	}
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("prop", "revisions")
+	params.Set("rvprop", "ids")
+	params.Set("redirects", "1")
+	params.Set("titles", strings.Join(titles, "|"))
+
+	resolved := map[string]string{}
+	revisions := map[string]int{}
+	for {
+		var response mediaWikiRevisionsResponse
+		if err := s.liveQuery(params, &response); err != nil {
+			return nil, err
+		}
+		for _, m := range response.Query.Normalized {
+			resolved[m.From] = m.To
+		}
+		for _, m := range response.Query.Redirects {
+			resolved[m.From] = m.To
+		}
+		for _, p := range response.Query.Pages {
+			if !p.Missing && len(p.Revisions) > 0 {
+				revisions[p.Title] = p.Revisions[0].RevID
+			}
+		}
+		if len(response.Continue) == 0 {
+			break
+		}
+		response.Continue.apply(params)
+	}
+
+	states := make([]*PageState, len(titles))
+	for i, title := range titles {
+		current := title
+		// A title may be normalized and then redirected.
+		for j := 0; j < 2; j++ {
+			if to, ok := resolved[current]; ok {
+				current = to
+			}
+		}
+		revision, ok := revisions[current]
+		states[i] = &PageState{Title: title, CurrentTitle: current, Revision: revision, Missing: !ok}
+	}
+	return states, nil
+}
@@ -0,0 +1,155 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+func TestMediaWikiScraperGetRevisionAt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("prop") != "revisions" || query.Get("rvdir") != "older" || query.Get("rvlimit") != "1" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		switch {
+		case query.Get("titles") != "Bear":
+			fmt.Fprintf(w, `{"query":{"pages":[{"title":%q,"missing":true}]}}`, query.Get("titles"))
+		case query.Get("rvstart") < "2001-01-01T00:00:00Z":
+			fmt.Fprint(w, `{"query":{"pages":[{"title":"Bear","revisions":[]}]}}`)
+		default:
+			fmt.Fprint(w, `{"query":{"pages":[{"title":"Bear","revisions":[{"revid":123,"timestamp":"2020-05-01T10:00:00Z"}]}]}}`)
+		}
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	// Test 1: Revision current at a time
+	got, err := scraper.GetRevisionAt("Bear", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to get revision: %v", err)
+	}
+	if got != 123 || scraper.RevisionPath(got) != "Special:Redirect/revision/123" {
+		t.Errorf("Revision mismatch. Got: %d, Want: 123", got)
+	}
+
+	// Test 2: Before the page was created, and missing pages
+	for _, path := range []string{"Bear", "Wolf"} {
+		_, err := scraper.GetRevisionAt(path, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+		if _, ok := err.(*scrape.MediaWikiAPIError); !ok {
+			t.Errorf("Expected a MediaWikiAPIError for %s, got %v", path, err)
+		}
+	}
+}
+
+func TestMediaWikiScraperGetHistory(t *testing.T) {
+	historyRequests, sizeRequests := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Has("revids") {
+			sizeRequests++
+		} else {
+			historyRequests++
+		}
+		switch {
+		case query.Get("revids") == "10":
+			fmt.Fprint(w, `{"query":{"pages":[{"title":"Bear","revisions":[{"revid":10,"size":90}]}]}}`)
+		case query.Get("titles") != "Bear":
+			fmt.Fprintf(w, `{"query":{"pages":[{"title":%q,"missing":true}]}}`, query.Get("titles"))
+		case query.Get("rvend") != "2020-01-01T00:00:00Z" || query.Get("rvdir") != "older":
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		case query.Get("rvcontinue") == "":
+			fmt.Fprint(w, `{"continue":{"rvcontinue":"20200301|12","continue":"||"},"query":{"pages":[{"title":"Bear","revisions":[`+
+				`{"revid":13,"parentid":12,"timestamp":"2020-04-01T00:00:00Z","user":"Alice","comment":"Expand diet","size":150,"tags":["visualeditor"]},`+
+				`{"revid":12,"parentid":11,"timestamp":"2020-03-01T00:00:00Z","user":"Bob","comment":"Typo","size":120,"minor":true,"tags":[]}]}]}}`)
+		default:
+			fmt.Fprint(w, `{"query":{"pages":[{"title":"Bear","revisions":[`+
+				`{"revid":11,"parentid":10,"timestamp":"2020-02-01T00:00:00Z","user":"Carol","comment":"","size":121,"tags":[]}]}]}}`)
+		}
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL, Cache: cache.New(t.TempDir(), time.Hour)}
+
+	// Test 1: Revisions across continued requests, with size deltas
+	got, err := scraper.GetHistory("Bear", &scrape.HistoryOptions{Since: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	want := []struct {
+		id, delta int
+		user      string
+	}{{13, 30, "Alice"}, {12, -1, "Bob"}, {11, 31, "Carol"}}
+	if len(got) != len(want) {
+		t.Fatalf("Revision count mismatch. Got: %d, Want: %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].ID != w.id || got[i].SizeDelta != w.delta || got[i].User != w.user {
+			t.Errorf("Revision %d mismatch. Got: %+v, Want: %+v", i, got[i], w)
+		}
+	}
+	if !got[1].Minor || len(got[0].Tags) != 1 {
+		t.Errorf("Flags or tags not read: %+v, %+v", got[0], got[1])
+	}
+
+	// Test 2: The history is fetched again every time, past revision sizes are not
+	if _, err := scraper.GetHistory("Bear", &scrape.HistoryOptions{Since: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if historyRequests != 4 || sizeRequests != 1 {
+		t.Errorf("Request count mismatch. Got: %d history, %d size, Want: 4 history, 1 size", historyRequests, sizeRequests)
+	}
+
+	// Test 3: Missing page
+	_, err = scraper.GetHistory("Wolf", &scrape.HistoryOptions{Since: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if _, ok := err.(*scrape.MediaWikiAPIError); !ok {
+		t.Errorf("Expected a MediaWikiAPIError, got %v", err)
+	}
+}
+
+func TestMediaWikiScraperGetLatestRevisions(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if titles := r.URL.Query().Get("titles"); titles != "Bear|Wolf|Old bear" {
+			t.Errorf("Unexpected titles: %s", titles)
+		}
+		fmt.Fprint(w, `{"query":{"redirects":[{"from":"Old bear","to":"Brown bear"}],"pages":[`+
+			`{"title":"Bear","revisions":[{"revid":20}]},`+
+			`{"title":"Wolf","missing":true},`+
+			`{"title":"Brown bear","revisions":[{"revid":31}]}]}}`)
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL, Cache: cache.New(t.TempDir(), time.Hour)}
+
+	// Test 1: Latest revisions, following redirects
+	got, err := scraper.GetLatestRevisions([]string{"Bear", "Wolf", "Old bear"})
+	if err != nil {
+		t.Fatalf("Failed to get latest revisions: %v", err)
+	}
+	want := []scrape.PageState{
+		{Title: "Bear", CurrentTitle: "Bear", Revision: 20},
+		{Title: "Wolf", CurrentTitle: "Wolf", Missing: true},
+		{Title: "Old bear", CurrentTitle: "Brown bear", Revision: 31},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("State count mismatch. Got: %d, Want: %d", len(got), len(want))
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("State %d mismatch. Got: %+v, Want: %+v", i, *got[i], want[i])
+		}
+	}
+
+	// Test 2: Latest revisions are looked up again every time
+	if _, err := scraper.GetLatestRevisions([]string{"Bear", "Wolf", "Old bear"}); err != nil {
+		t.Fatalf("Failed to get latest revisions: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Request count mismatch. Got: %d, Want: 2", requests)
+	}
+}
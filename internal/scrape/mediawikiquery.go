@@ -0,0 +1,398 @@
+package scrape
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Maximum number of results a MediaWiki list query returns per request
+// for clients without the apihighlimits right.
+const mediaWikiMaxLimit = 500
+
+// mediaWikiContinue holds the continuation values returned by a MediaWiki
+// query when more results are available. They are passed back unchanged
+// as request parameters to retrieve the next batch.
+type mediaWikiContinue map[string]any
+
+// apply copies the continuation values into the parameters of the next request.
+func (c mediaWikiContinue) apply(params url.Values) {
+	for k, v := range c {
+		switch v := v.(type) {
+		case string:
+			params.Set(k, v)
+		case float64:
+			params.Set(k, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+}
+
+// Representation of the json response returned by a list=search query.
+type mediaWikiSearchResponse struct {
+	Continue mediaWikiContinue `json:"continue"`
+	Query    struct {
+		Search []struct {
+			Title     string `json:"title"`
+			Snippet   string `json:"snippet"`
+			Size      int    `json:"size"`
+			WordCount int    `json:"wordcount"`
+			Timestamp string `json:"timestamp"`
+		} `json:"search"`
+	} `json:"query"`
+	Error *MediaWikiAPIError `json:"error"`
+}
+
+func (r *mediaWikiSearchResponse) apiError() *MediaWikiAPIError { return r.Error }
+
+// joinInts joins integers with "|", the MediaWiki multi-value separator.
+func joinInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.Itoa(v)
+	}
+	return strings.Join(strs, "|")
+}
+
+// batchLimit returns the number of results to request next, given the
+// number of results still wanted. A remaining of 0 or less means no limit.
+func batchLimit(remaining int) string {
+	if remaining <= 0 || remaining > mediaWikiMaxLimit {
+		return "max"
+	}
+	return strconv.Itoa(remaining)
+}
+
+// Search performs a full text search of the wiki using list=search,
+// following continuation until opts.Limit results have been collected
+// or no results remain. Snippets are returned as plain text.
+func (s *MediaWikiScraper) Search(query string, opts *SearchOptions) ([]*SearchResult, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("list", "search")
+	params.Set("srsearch", query)
+	params.Set("srprop", "snippet|size|wordcount|timestamp")
+	if len(opts.Namespaces) > 0 {
+		params.Set("srnamespace", joinInts(opts.Namespaces))
+	}
+	var results []*SearchResult
+	for len(results) < limit {
+		params.Set("srlimit", batchLimit(limit-len(results)))
+		var response mediaWikiSearchResponse
+		if err := s.query(params, &response); err != nil {
+			return nil, err
+		}
+		for _, r := range response.Query.Search {
+			results = append(results, &SearchResult{
+				Title:     r.Title,
+				Snippet:   stripTags(r.Snippet),
+				Size:      r.Size,
+				WordCount: r.WordCount,
+				Timestamp: r.Timestamp,
+			})
+		}
+		if len(response.Continue) == 0 {
+			break
+		}
+		response.Continue.apply(params)
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Namespace number of category pages on every MediaWiki.
+const mediaWikiCategoryNamespace = 14
+
+// Representation of the json response returned by a query using
+// generator=categorymembers with formatversion=2.
+type mediaWikiCategoryResponse struct {
+	Continue mediaWikiContinue `json:"continue"`
+	Query    struct {
+		Pages []struct {
+			Title string `json:"title"`
+			NS    int    `json:"ns"`
+		} `json:"pages"`
+	} `json:"query"`
+	Error *MediaWikiAPIError `json:"error"`
+}
+
+func (r *mediaWikiCategoryResponse) apiError() *MediaWikiAPIError { return r.Error }
+
+// categoryMember is a page belonging to a category along with its namespace.
+type categoryMember struct {
+	Title string
+	NS    int
+}
+
+// fetchCategoryMembers lists every member of a single category, in all
+// namespaces, following continuation until the category is exhausted.
+func (s *MediaWikiScraper) fetchCategoryMembers(category string) ([]categoryMember, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("generator", "categorymembers")
+	params.Set("gcmtitle", category)
+	params.Set("gcmlimit", "max")
+	var members []categoryMember
+	for {
+		var response mediaWikiCategoryResponse
+		if err := s.query(params, &response); err != nil {
+			return nil, err
+		}
+		for _, p := range response.Query.Pages {
+			members = append(members, categoryMember{Title: p.Title, NS: p.NS})
+		}
+		if len(response.Continue) == 0 {
+			return members, nil
+		}
+		response.Continue.apply(params)
+	}
+}
+
+// GetCategoryMembers walks a category breadth first, descending into
+// subcategories up to opts.Depth levels, and returns the deduplicated
+// titles of members in the requested namespaces. The "Category:" prefix
+// is added to the category name when missing.
+func (s *MediaWikiScraper) GetCategoryMembers(category string, opts *CategoryOptions) ([]string, error) {
+	if opts == nil {
+		opts = &CategoryOptions{}
+	}
+	namespaces := map[int]bool{}
+	for _, ns := range opts.Namespaces {
+		namespaces[ns] = true
+	}
+	if len(namespaces) == 0 {
+		namespaces[0] = true
+	}
+	if !strings.HasPrefix(strings.ToLower(category), "category:") {
+		category = "Category:" + category
+	}
+
+	var titles []string
+	seen := map[string]bool{}
+	visited := map[string]bool{category: true}
+	level := []string{category}
+	for depth := 0; len(level) > 0; depth++ {
+		var next []string
+		for _, cat := range level {
+			members, err := s.fetchCategoryMembers(cat)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range members {
+				if namespaces[m.NS] && !seen[m.Title] {
+					seen[m.Title] = true
+					titles = append(titles, m.Title)
+				}
+				if m.NS == mediaWikiCategoryNamespace && depth < opts.Depth && !visited[m.Title] {
+					visited[m.Title] = true
+					next = append(next, m.Title)
+				}
+			}
+		}
+		level = next
+	}
+	return titles, nil
+}
+
+// Representation of the json response returned by a prop=links
+// query for a single title with formatversion=2.
+type mediaWikiLinksResponse struct {
+	Continue mediaWikiContinue `json:"continue"`
+	Query    struct {
+		Pages []struct {
+			Title   string `json:"title"`
+			Missing bool   `json:"missing"`
+			Links   []struct {
+				Title string `json:"title"`
+			} `json:"links"`
+		} `json:"pages"`
+	} `json:"query"`
+	Error *MediaWikiAPIError `json:"error"`
+}
+
+func (r *mediaWikiLinksResponse) apiError() *MediaWikiAPIError { return r.Error }
+
+// GetLinks lists the internal links of the page specified by path using
+// prop=links, following redirects and continuation. Returns the canonical
+// title of the page and the titles it links to.
+//
+// Can error when:
+//   - The page does not exist
+func (s *MediaWikiScraper) GetLinks(path string, namespaces []int) (string, []string, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("prop", "links")
+	params.Set("titles", path)
+	params.Set("redirects", "1")
+	params.Set("pllimit", "max")
+	if len(namespaces) == 0 {
+		namespaces = []int{0}
+	}
+	params.Set("plnamespace", joinInts(namespaces))
+	var title string
+	var links []string
+	for {
+		var response mediaWikiLinksResponse
+		if err := s.query(params, &response); err != nil {
+			return "", nil, err
+		}
+		for _, p := range response.Query.Pages {
+			if p.Missing {
+				return "", nil, &MediaWikiAPIError{
+					Code: "missingtitle",
+					Info: fmt.Sprintf("The page %s does not exist", p.Title),
+				}
+			}
+			title = p.Title
+			for _, l := range p.Links {
+				links = append(links, l.Title)
+			}
+		}
+		if len(response.Continue) == 0 {
+			return title, links, nil
+		}
+		response.Continue.apply(params)
+	}
+}
+
+// Maximum number of titles a MediaWiki query accepts per request
+// for clients without the apihighlimits right.
+const mediaWikiMaxTitles = 50
+
+// Representation of the json response returned by a prop=revisions
+// query with formatversion=2, either for the current wikitext of several
+// titles or for revisions of a single title.
+type mediaWikiRevisionsResponse struct {
+	Continue mediaWikiContinue `json:"continue"`
+	Query    struct {
+		Normalized []mediaWikiTitleMapping `json:"normalized"`
+		Redirects  []mediaWikiTitleMapping `json:"redirects"`
+		Pages      []struct {
+			Title     string `json:"title"`
+			Missing   bool   `json:"missing"`
+			Revisions []struct {
+				RevID     int      `json:"revid"`
+				ParentID  int      `json:"parentid"`
+				Timestamp string   `json:"timestamp"`
+				User      string   `json:"user"`
+				Comment   string   `json:"comment"`
+				Size      int      `json:"size"`
+				Minor     bool     `json:"minor"`
+				Tags      []string `json:"tags"`
+				Slots     struct {
+					Main struct {
+						Content string `json:"content"`
+					} `json:"main"`
+				} `json:"slots"`
+			} `json:"revisions"`
+		} `json:"pages"`
+	} `json:"query"`
+	Error *MediaWikiAPIError `json:"error"`
+}
+
+func (r *mediaWikiRevisionsResponse) apiError() *MediaWikiAPIError { return r.Error }
+
+// A title normalization or redirect reported by a MediaWiki query.
+type mediaWikiTitleMapping struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BatchSize returns the maximum number of pages GetPages accepts.
+func (s *MediaWikiScraper) BatchSize() int {
+	return mediaWikiMaxTitles
+}
+
+// GetPages fetches the current wikitext of up to BatchSize pages in a single
+// prop=revisions query, following redirects and continuation, and parses it
+// with ParseWikitextSections. This trades the fidelity of the rendered HTML
+// for far fewer requests when scraping large manifests.
+//
+// Can error when:
+//   - More than BatchSize paths are requested
+//   - The query fails
+func (s *MediaWikiScraper) GetPages(paths []string) ([]*Page, []string, error) {
+	if len(paths) > mediaWikiMaxTitles {
+		return nil, nil, fmt.Errorf("cannot fetch %d pages in one batch, the limit is %d", len(paths), mediaWikiMaxTitles)
+	}
+	titles := make([]string, len(paths))
+	for i, path := range paths {
+		title, err := url.QueryUnescape(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		titles[i] = title
+	}
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("prop", "revisions")
+	params.Set("rvprop", "ids|content")
+	params.Set("rvslots", "main")
+	params.Set("redirects", "1")
+	params.Set("titles", strings.Join(titles, "|"))
+
+	resolved := map[string]string{}
+	content := map[string]string{}
+	revisions := map[string]int{}
+	for {
+		var response mediaWikiRevisionsResponse
+		if err := s.query(params, &response); err != nil {
+			return nil, nil, err
+		}
+		for _, m := range response.Query.Normalized {
+			resolved[m.From] = m.To
+		}
+		for _, m := range response.Query.Redirects {
+			resolved[m.From] = m.To
+		}
+		for _, p := range response.Query.Pages {
+			if !p.Missing && len(p.Revisions) > 0 {
+				content[p.Title] = p.Revisions[0].Slots.Main.Content
+				revisions[p.Title] = p.Revisions[0].RevID
+			}
+		}
+		if len(response.Continue) == 0 {
+			break
+		}
+		response.Continue.apply(params)
+	}
+
+	var pages []*Page
+	var missing []string
+	seen := map[string]bool{}
+	for i, title := range titles {
+		// A title may be normalized and then redirected.
+		for j := 0; j < 2; j++ {
+			if to, ok := resolved[title]; ok {
+				title = to
+			}
+		}
+		text, ok := content[title]
+		if !ok {
+			missing = append(missing, paths[i])
+			continue
+		}
+		if seen[title] {
+			continue
+		}
+		seen[title] = true
+		pages = append(pages, &Page{
+			Title:    title,
+			Revision: revisions[title],
+			Sections: ParseWikitextSections(text),
+		})
+	}
+	return pages, missing, nil
+}
@@ -0,0 +1,176 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+func TestMediaWikiScraperSearch(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		requests = append(requests, r.URL.RawQuery)
+		if query.Get("list") != "search" || query.Get("srsearch") != "dragon" || query.Get("srnamespace") != "0|14" {
+			t.Errorf("Unexpected search query: %s", r.URL.RawQuery)
+		}
+		switch query.Get("sroffset") {
+		case "":
+			fmt.Fprint(w, `{"continue":{"sroffset":2,"continue":"-||"},"query":{"search":[`+
+				`{"title":"Dragon","snippet":"A <span class=\"searchmatch\">dragon</span> is big","size":100},`+
+				`{"title":"Dragon slayer I","snippet":"","size":200}]}}`)
+		case "2":
+			fmt.Fprint(w, `{"query":{"search":[{"title":"Category:Dragons","snippet":"","size":10}]}}`)
+		default:
+			t.Errorf("Unexpected offset: %s", query.Get("sroffset"))
+		}
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	results, err := scraper.Search("dragon", &scrape.SearchOptions{Limit: 50, Namespaces: []int{0, 14}})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	want := []string{"Dragon", "Dragon slayer I", "Category:Dragons"}
+	if len(results) != len(want) {
+		t.Fatalf("Result count mismatch. Got: %d, Want: %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Title != want[i] {
+			t.Errorf("Result mismatch at index %d. Got: %s, Want: %s", i, r.Title, want[i])
+		}
+	}
+	if results[0].Snippet != "A dragon is big" {
+		t.Errorf("Snippet was not stripped of markup. Got: %s", results[0].Snippet)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Request count mismatch. Got: %d, Want: 2", len(requests))
+	}
+}
+
+func TestMediaWikiScraperGetCategoryMembers(t *testing.T) {
+	members := map[string]string{
+		"Category:Quests": `{"continue":{"gcmcontinue":"page|2","continue":"gcmcontinue||"},"query":{"pages":[` +
+			`{"title":"Cook's Assistant","ns":0},{"title":"Category:Members' quests","ns":14}]}}`,
+		"Category:Quests|page|2": `{"query":{"pages":[{"title":"Dragon Slayer I","ns":0},{"title":"Template:Quest","ns":10}]}}`,
+		"Category:Members' quests": `{"query":{"pages":[{"title":"Dragon Slayer I","ns":0},{"title":"Lost City","ns":0},` +
+			`{"title":"Category:Quests","ns":14}]}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		key := query.Get("gcmtitle")
+		if c := query.Get("gcmcontinue"); c != "" {
+			key += "|" + c
+		}
+		body, ok := members[key]
+		if !ok {
+			t.Errorf("Unexpected category query: %s", r.URL.RawQuery)
+			body = `{"query":{"pages":[]}}`
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	// Test 1: Direct members only
+	got, err := scraper.GetCategoryMembers("Quests", nil)
+	if err != nil {
+		t.Fatalf("Failed to list category members: %v", err)
+	}
+	want := []string{"Cook's Assistant", "Dragon Slayer I"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Members mismatch. Got: %v, Want: %v", got, want)
+	}
+
+	// Test 2: Recursive with namespace filter, deduplicated and without revisiting cycles
+	got, err = scraper.GetCategoryMembers("Category:Quests", &scrape.CategoryOptions{Depth: 5, Namespaces: []int{0, 14}})
+	if err != nil {
+		t.Fatalf("Failed to list category members: %v", err)
+	}
+	want = []string{"Cook's Assistant", "Category:Members' quests", "Dragon Slayer I", "Lost City", "Category:Quests"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Members mismatch. Got: %v, Want: %v", got, want)
+	}
+}
+
+func TestMediaWikiScraperGetLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case query.Get("titles") != "Dragon":
+			fmt.Fprintf(w, `{"query":{"pages":[{"title":%q,"missing":true}]}}`, query.Get("titles"))
+		case query.Get("plcontinue") == "":
+			fmt.Fprint(w, `{"continue":{"plcontinue":"1|0|Elvarg","continue":"||"},"query":{"pages":[{"title":"Dragons","links":[{"title":"Crandor"}]}]}}`)
+		default:
+			fmt.Fprint(w, `{"query":{"pages":[{"title":"Dragons","links":[{"title":"Elvarg"}]}]}}`)
+		}
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	// Test 1: Redirected page with continuation
+	title, links, err := scraper.GetLinks("Dragon", nil)
+	if err != nil {
+		t.Fatalf("Failed to list links: %v", err)
+	}
+	if title != "Dragons" || fmt.Sprint(links) != "[Crandor Elvarg]" {
+		t.Errorf("Unexpected links. Got: %s %v", title, links)
+	}
+
+	// Test 2: Missing page
+	_, _, err = scraper.GetLinks("Cheesebiscuit", nil)
+	if _, ok := err.(*scrape.MediaWikiAPIError); !ok {
+		t.Errorf("Expected a MediaWikiAPIError for a missing page, got %v", err)
+	}
+}
+
+func TestMediaWikiScraperGetPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("titles") != "Dragon_slayer_I|Dragon|Cheesebiscuit|Elvarg|Dragons" {
+			t.Errorf("Unexpected titles: %s", query.Get("titles"))
+		}
+		if query.Get("rvcontinue") == "" {
+			fmt.Fprint(w, `{"continue":{"rvcontinue":"42|100","continue":"||"},"query":{`+
+				`"normalized":[{"from":"Dragon_slayer_I","to":"Dragon slayer I"}],`+
+				`"redirects":[{"from":"Dragon","to":"Dragons"}],`+
+				`"pages":[{"title":"Cheesebiscuit","missing":true},`+
+				`{"title":"Dragons","revisions":[{"slots":{"main":{"content":"Big [[lizard]]s.\n== Types ==\nMetal."}}}]},`+
+				`{"title":"Dragon slayer I","revisions":[{"slots":{"main":{"content":"A quest."}}}]},`+
+				`{"title":"Elvarg"}]}}`)
+			return
+		}
+		fmt.Fprint(w, `{"query":{"pages":[{"title":"Elvarg","revisions":[{"slots":{"main":{"content":"A dragon."}}}]}]}}`)
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	// A redirect and its target are the same page, returned once
+	pages, missing, err := scraper.GetPages([]string{"Dragon_slayer_I", "Dragon", "Cheesebiscuit", "Elvarg", "Dragons"})
+	if err != nil {
+		t.Fatalf("Failed to get pages: %v", err)
+	}
+	if fmt.Sprint(missing) != "[Cheesebiscuit]" {
+		t.Errorf("Unexpected missing pages: %v", missing)
+	}
+	var titles []string
+	for _, p := range pages {
+		titles = append(titles, p.Title)
+	}
+	if fmt.Sprint(titles) != "[Dragon slayer I Dragons Elvarg]" {
+		t.Fatalf("Unexpected pages: %v", titles)
+	}
+	if len(pages[1].Sections) != 2 || pages[1].Sections[0].Content != "Big lizards.\n" || pages[1].Sections[1].Heading != "Types" {
+		t.Errorf("Unexpected sections for Dragons: %+v %+v", *pages[1].Sections[0], *pages[1].Sections[1])
+	}
+
+	// Too many titles
+	_, _, err = scraper.GetPages(make([]string, scraper.BatchSize()+1))
+	if err == nil {
+		t.Error("Expected an error for an oversized batch, but got nil")
+	}
+}
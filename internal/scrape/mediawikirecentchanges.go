@@ -0,0 +1,87 @@
+package scrape
+
+import (
+	"net/url"
+	"time"
+)
+
+// Representation of the json response returned by a list=recentchanges
+// query with formatversion=2.
+type mediaWikiRecentChangesResponse struct {
+	Continue mediaWikiContinue `json:"continue"`
+	Query    struct {
+		RecentChanges []struct {
+			Type      string `json:"type"`
+			Title     string `json:"title"`
+			RCID      int    `json:"rcid"`
+			RevID     int    `json:"revid"`
+			Timestamp string `json:"timestamp"`
+			LogType   string `json:"logtype"`
+			LogAction string `json:"logaction"`
+			LogParams struct {
+				TargetTitle string `json:"target_title"`
+			} `json:"logparams"`
+		} `json:"recentchanges"`
+	} `json:"query"`
+	Error *MediaWikiAPIError `json:"error"`
+}
+
+func (r *mediaWikiRecentChangesResponse) apiError() *MediaWikiAPIError { return r.Error }
+
+// GetRecentChanges lists the edits, page creations, moves and deletions
+// made since opts.Since, oldest first, following continuation. Restored
+// pages are reported as edits, and other logged actions are omitted. The
+// changes are never served from the cache.
+//
+// Can error when:
+//   - The query fails
+func (s *MediaWikiScraper) GetRecentChanges(opts *RecentChangesOptions) ([]*RecentChange, error) {
+	if opts == nil {
+		opts = &RecentChangesOptions{}
+	}
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []int{0}
+	}
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("list", "recentchanges")
+	params.Set("rcprop", "title|ids|timestamp|loginfo")
+	params.Set("rctype", "edit|new|log")
+	params.Set("rcdir", "newer")
+	params.Set("rclimit", "max")
+	params.Set("rcnamespace", joinInts(namespaces))
+	if !opts.Since.IsZero() {
+		params.Set("rcstart", opts.Since.UTC().Format(time.RFC3339))
+	}
+
+	var changes []*RecentChange
+	for {
+		var response mediaWikiRecentChangesResponse
+		if err := s.liveQuery(params, &response); err != nil {
+			return nil, err
+		}
+		for _, rc := range response.Query.RecentChanges {
+			change := &RecentChange{ID: rc.RCID, Type: rc.Type, Title: rc.Title, Revision: rc.RevID, Timestamp: rc.Timestamp}
+			if rc.Type == "log" {
+				switch {
+				case rc.LogType == "move":
+					change.Type = "move"
+					change.NewTitle = rc.LogParams.TargetTitle
+				case rc.LogType == "delete" && rc.LogAction == "delete":
+					change.Type = "delete"
+				case rc.LogType == "delete" && rc.LogAction == "restore":
+					change.Type = "edit"
+				default:
+					continue
+				}
+			}
+			changes = append(changes, change)
+		}
+		if len(response.Continue) == 0 {
+			return changes, nil
+		}
+		response.Continue.apply(params)
+	}
+}
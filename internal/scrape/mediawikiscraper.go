@@ -3,22 +3,40 @@ package scrape
 import (
 	"bytes"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 	jsoniter "github.com/json-iterator/go"
-	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
+// Pages naming a revision or page id through Special:Redirect, such as
+// "Special:Redirect/revision/123", which pageParams requests directly.
+var mediaWikiRedirect = regexp.MustCompile(`^(?i:Special):Redirect/(revision|page)/(\d+)$`)
+
 // Wraps methods for retrieving and parsing pages on
-// a MediaWiki based website.
+// a MediaWiki based website. API responses are cached
+// when Cache is not nil. Elements of the page HTML
+// matching the Ignore selector, such as navigation
+// boxes added by a wiki's templates, are removed
+// before parsing.
 type MediaWikiScraper struct {
 	BaseURL string
+	Cache   *cache.Cache
+	Ignore  string
+}
+
+// mediaWikiResponse is implemented by every MediaWiki API response
+// representation so that API errors can be surfaced uniformly.
+type mediaWikiResponse interface {
+	apiError() *MediaWikiAPIError
 }
 
 // Representation of the json response returned
@@ -26,11 +44,41 @@ type MediaWikiScraper struct {
 type mediaWikiPageResponse struct {
 	Parse struct {
 		Title string `json:"title"`
+		RevID int    `json:"revid"`
 		Text  struct {
 			Value string `json:"*"`
 		} `json:"text"`
 	} `json:"parse"`
 	Error *MediaWikiAPIError `json:"error"`
+	// Selector of elements removed before parsing
+	ignore string
+}
+
+func (r *mediaWikiPageResponse) apiError() *MediaWikiAPIError { return r.Error }
+
+// Representation of the json response returned by making a
+// request for the section list (prop=sections) of a MediaWiki page
+type mediaWikiSectionsResponse struct {
+	Parse struct {
+		Title    string                  `json:"title"`
+		RevID    int                     `json:"revid"`
+		Sections []*mediaWikiSectionInfo `json:"sections"`
+	} `json:"parse"`
+	Error *MediaWikiAPIError `json:"error"`
+}
+
+func (r *mediaWikiSectionsResponse) apiError() *MediaWikiAPIError { return r.Error }
+
+// A single entry of a MediaWiki section list. Index is the
+// identifier accepted by the section parameter of action=parse,
+// Number is the dotted table of contents number (e.g. "2.1").
+type mediaWikiSectionInfo struct {
+	TocLevel   int    `json:"toclevel"`
+	Line       string `json:"line"`
+	Number     string `json:"number"`
+	Index      string `json:"index"`
+	ByteOffset *int   `json:"byteoffset"`
+	Anchor     string `json:"anchor"`
 }
 
 // MediaWiki API error format, for a list of error codes
@@ -47,7 +95,7 @@ func (e *MediaWikiAPIError) Error() string {
 	return fmt.Sprintf("MediaWiki API error: [code] %s [info] %s", e.Code, e.Info)
 }
 
-// pageQuery builds an encoded Media Wiki page request url given the path of a page.
+// pageParams builds the Media Wiki parse request parameters given the path of a page.
 //
 // In this case,
 // 'path' refers to the segment of a Media Wiki url which holds the unique page
@@ -63,52 +111,189 @@ func (e *MediaWikiAPIError) Error() string {
 //
 // With a known page prefix: "wiki/", mapped to by the host name, we can simply
 // strip this from the path and receive the page name.
-func (s *MediaWikiScraper) pageQuery(path string) (string, error) {
+//
+// Paths naming a revision or page id through Special:Redirect
+// ("Special:Redirect/revision/123" or "Special:Redirect/page/456") request
+// that revision or page by id.
+func (s *MediaWikiScraper) pageParams(path string) (url.Values, error) {
 	path, err := url.QueryUnescape(path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	params := url.Values{}
 	params.Set("action", "parse")
-	params.Set("format", "json")
+	if m := mediaWikiRedirect.FindStringSubmatch(strings.ReplaceAll(path, " ", "_")); m != nil {
+		if m[1] == "revision" {
+			params.Set("oldid", m[2])
+		} else {
+			params.Set("pageid", m[2])
+		}
+		return params, nil
+	}
 	params.Set("page", path)
-	return s.BaseURL + "?" + params.Encode(), nil
+	return params, nil
 }
 
-// fetchPage makes a http request to the MediaWiki API endpoint
-// for the page specified by the path, then unmarshals the response.
-// Returns a mediaWikiPageResponse.
+// query makes a http request to the MediaWiki API endpoint with the
+// provided params and unmarshals the json response into result. Responses
+// are served from and stored in the scraper's Cache when one is set, except
+// for API errors, which are never cached.
 // Can return a MediaWikiAPIError if (for example):
 //   - The page does not exist
 //   - The user is denied read access to the page
 //   - The user has been rate-limited and should try again
-func (s *MediaWikiScraper) fetchPage(path string) (*mediaWikiPageResponse, error) {
-	var result mediaWikiPageResponse
-	url, err := s.pageQuery(path)
+func (s *MediaWikiScraper) query(params url.Values, result mediaWikiResponse) error {
+	return s.queryUsing(s.fetch, params, result)
+}
+
+// liveQuery is query without the cache, for responses which change with
+// every edit to the wiki, such as revision lookups and recent changes.
+func (s *MediaWikiScraper) liveQuery(params url.Values, result mediaWikiResponse) error {
+	return s.queryUsing(func(rawURL string) ([]byte, error) {
+		return s.Cache.FetchLive(http.DefaultClient, rawURL)
+	}, params, result)
+}
+
+// parseQuery is query for action=parse requests. A revision requested by
+// oldid never changes, so its parse is served from the cache whatever its
+// age. A stale cached parse of the current version of a page is kept when
+// the page's latest revision is still the one parsed, and fetched again
+// otherwise.
+func (s *MediaWikiScraper) parseQuery(params url.Values, result mediaWikiResponse) error {
+	if params.Has("oldid") {
+		return s.queryUsing(func(rawURL string) ([]byte, error) {
+			return s.Cache.FetchWithin(http.DefaultClient, rawURL, math.MaxInt64)
+		}, params, result)
+	}
+	return s.queryUsing(func(rawURL string) ([]byte, error) {
+		if entry := s.revalidate(rawURL, params); entry != nil {
+			return []byte(entry.Body), nil
+		}
+		return s.fetch(rawURL)
+	}, params, result)
+}
+
+// revalidate returns the stale cached parse of reqURL, requested with
+// params, refreshed, when the parsed revision is still the latest revision
+// of the page. Returns nil when there is no stale entry or it cannot be
+// revalidated, leaving it to be fetched again.
+func (s *MediaWikiScraper) revalidate(reqURL string, params url.Values) *cache.Entry {
+	if s.Cache == nil || s.Cache.Offline {
+		return nil
+	}
+	entry, err := s.Cache.Get(reqURL, nil, "")
+	if err != nil || entry == nil || entry.Age() < s.Cache.TTL {
+		return nil
+	}
+	var cached struct {
+		Parse struct {
+			RevID int `json:"revid"`
+		} `json:"parse"`
+	}
+	if err := json.Unmarshal([]byte(entry.Body), &cached); err != nil || cached.Parse.RevID == 0 {
+		return nil
+	}
+	latest, err := s.latestRevision(params)
+	if err != nil || latest != cached.Parse.RevID {
+		return nil
+	}
+	if err := s.Cache.Touch(entry); err != nil {
+		return nil
+	}
+	return entry
+}
+
+// latestRevision looks up the id of the latest revision of the page named
+// by the page or pageid parameter of a parse request. Redirects are not
+// followed, as action=parse does not follow them either.
+func (s *MediaWikiScraper) latestRevision(parseParams url.Values) (int, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("formatversion", "2")
+	params.Set("prop", "revisions")
+	params.Set("rvprop", "ids")
+	if parseParams.Has("pageid") {
+		params.Set("pageids", parseParams.Get("pageid"))
+	} else {
+		params.Set("titles", parseParams.Get("page"))
+	}
+	var response mediaWikiRevisionsResponse
+	if err := s.liveQuery(params, &response); err != nil {
+		return 0, err
+	}
+	if len(response.Query.Pages) == 0 || len(response.Query.Pages[0].Revisions) == 0 {
+		return 0, fmt.Errorf("no latest revision of %s", parseParams.Encode())
+	}
+	return response.Query.Pages[0].Revisions[0].RevID, nil
+}
+
+// queryUsing makes the request of query through fetch.
+func (s *MediaWikiScraper) queryUsing(fetch func(rawURL string) ([]byte, error), params url.Values, result mediaWikiResponse) error {
+	params.Set("format", "json")
+	reqURL := s.BaseURL + "?" + params.Encode()
+	body, err := fetch(reqURL)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	res, err := http.Get(url)
+	err = json.NewDecoder(bytes.NewReader(body)).Decode(result)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer res.Body.Close()
 
-	var buf bytes.Buffer
-	_, err = io.Copy(&buf, res.Body)
-	// body, err := io.ReadAll(res.Body)
+	if apiErr := result.apiError(); apiErr != nil {
+		if s.Cache != nil {
+			s.Cache.Remove(reqURL, nil, "")
+		}
+		return apiErr
+	}
+	return nil
+}
+
+// fetch returns the body of a GET request to rawURL, through the
+// scraper's Cache when one is set.
+func (s *MediaWikiScraper) fetch(rawURL string) ([]byte, error) {
+	return s.Cache.Fetch(http.DefaultClient, rawURL)
+}
+
+// fetchPage makes a request for the full HTML of the page specified
+// by the path. Returns a mediaWikiPageResponse.
+func (s *MediaWikiScraper) fetchPage(path string) (*mediaWikiPageResponse, error) {
+	return s.fetchSection(path, "")
+}
+
+// fetchSection makes a request for the HTML of a single section of the
+// page specified by the path, where index is the MediaWiki section
+// identifier. An empty index requests the whole page.
+// Returns a mediaWikiPageResponse.
+func (s *MediaWikiScraper) fetchSection(path string, index string) (*mediaWikiPageResponse, error) {
+	result := mediaWikiPageResponse{ignore: s.Ignore}
+	params, err := s.pageParams(path)
 	if err != nil {
 		return nil, err
 	}
-	err = json.NewDecoder(&buf).Decode(&result)
-	if err != nil {
+	if index != "" {
+		params.Set("prop", "text|revid")
+		params.Set("section", index)
+	}
+	if err := s.parseQuery(params, &result); err != nil {
 		return nil, err
 	}
+	return &result, nil
+}
 
-	if result.Error != nil {
-		return nil, result.Error
+// fetchSectionList makes a lightweight request for the section list
+// (headings, levels and identifiers, but no content) of the page
+// specified by the path. Returns a mediaWikiSectionsResponse.
+func (s *MediaWikiScraper) fetchSectionList(path string) (*mediaWikiSectionsResponse, error) {
+	var result mediaWikiSectionsResponse
+	params, err := s.pageParams(path)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("prop", "sections|revid")
+	if err := s.parseQuery(params, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -130,26 +315,127 @@ func (s *MediaWikiScraper) GetPage(path string) (*Page, error) {
 	}
 	return &Page{
 		Title:    response.Parse.Title,
+		Revision: response.Parse.RevID,
 		Sections: sections,
 	}, nil
 }
 
-// GetSection searches for a section of a page by heading, and returns it if found.
-func (s *MediaWikiScraper) GetSection(path string, heading string) (*Page, error) {
-	response, err := s.fetchPage(path)
+// GetSections returns only the sections of the page specified by path that
+// are selected by the filter. When the filter can select only a single
+// section, the section list is queried first and only that section is
+// fetched, from the same revision. Otherwise the whole page is fetched once
+// and filtered, as fetching several sections individually would cost more
+// requests.
+// Each Section keeps the index it would have in a full GetPage result.
+//
+// Can error when:
+//   - section list or section fetch fails.
+//   - section parsing fails
+//   - no section matches the filter
+func (s *MediaWikiScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	if !filter.selectsOne() {
+		return getFilteredPage(s, path, filter)
+	}
+	list, err := s.fetchSectionList(path)
+	if err != nil {
+		return nil, err
+	}
+	stubs, ids := list.topLevelSections()
+	matched, err := filter.Apply(list.Parse.Title, stubs)
+	if err != nil {
+		return nil, err
+	}
+	stub := matched[0]
+	id := ids[stub.Index]
+	if _, err := strconv.Atoi(id); len(matched) > 1 || err != nil {
+		// Repeated headings match several sections, and sections
+		// transcluded from templates cannot be requested individually.
+		return getFilteredPage(s, path, filter)
+	}
+	if list.Parse.RevID != 0 {
+		path = s.RevisionPath(list.Parse.RevID)
+	}
+	response, err := s.fetchSection(path, id)
 	if err != nil {
 		return nil, err
 	}
-	section, err := response.ParseSection(heading)
+	content, err := response.parseSectionContent(stub.Index)
 	if err != nil {
 		return nil, err
 	}
+	stub.Content = content
 	return &Page{
-		Title:    response.Parse.Title,
-		Sections: []*Section{section},
+		Title:    list.Parse.Title,
+		Revision: list.Parse.RevID,
+		Sections: []*Section{stub},
+		Filter:   filter,
 	}, nil
 }
 
+// GetTOC lists every section of the page specified by path, at all
+// levels, using a lightweight section list request.
+func (s *MediaWikiScraper) GetTOC(path string) (*TOC, error) {
+	list, err := s.fetchSectionList(path)
+	if err != nil {
+		return nil, err
+	}
+	toc := &TOC{Title: list.Parse.Title}
+	for _, info := range list.Parse.Sections {
+		toc.Entries = append(toc.Entries, &TOCEntry{
+			Level:      info.TocLevel,
+			Number:     info.Number,
+			Heading:    stripTags(info.Line),
+			Anchor:     info.Anchor,
+			ByteOffset: info.ByteOffset,
+		})
+	}
+	return toc, nil
+}
+
+// topLevelSections converts a section list into content-less Sections
+// indexed the same way as ParseSections: the introduction is 0 and each
+// top level heading follows in order. Also returns the MediaWiki section
+// identifier for each of those indices.
+func (response *mediaWikiSectionsResponse) topLevelSections() ([]*Section, []string) {
+	stubs := []*Section{{Heading: "Introduction", Index: 0}}
+	ids := []string{"0"}
+	for _, info := range response.Parse.Sections {
+		if info.TocLevel != 1 {
+			continue
+		}
+		stubs = append(stubs, &Section{
+			Heading: stripTags(info.Line),
+			Index:   len(stubs),
+		})
+		ids = append(ids, info.Index)
+	}
+	return stubs, ids
+}
+
+// parseSectionContent parses the body text from the response to a single
+// section request. The introduction (index 0) has no heading, every other
+// section response begins with its own heading.
+func (response *mediaWikiPageResponse) parseSectionContent(index int) (string, error) {
+	sections, err := response.ParseSections()
+	if err != nil {
+		return "", err
+	}
+	if index == 0 || len(sections) < 2 {
+		return sections[0].Content, nil
+	}
+	return sections[1].Content, nil
+}
+
+// stripTags returns the text content of an HTML fragment, such as
+// the formatted heading line of a MediaWiki section list entry.
+func stripTags(fragment string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return fragment
+	}
+	return doc.Text()
+}
+
 // ParseSections parses raw HTML from mediaWikiPageResponse.
 // Returns an array of Sections containing headlines and body text.
 //
@@ -158,13 +444,21 @@ func (s *MediaWikiScraper) GetSection(path string, heading string) (*Page, error
 //
 // TODO: Add table parsing support
 func (response *mediaWikiPageResponse) ParseSections() ([]*Section, error) {
+	return parseMediaWikiHTML(response.Parse.Text.Value, response.ignore)
+}
+
+// parseMediaWikiHTML parses the rendered HTML of a MediaWiki page, as
+// returned by the parse API or stored in offline archives, into Sections.
+// Elements matching ignore are removed first.
+func parseMediaWikiHTML(html string, ignore string) ([]*Section, error) {
 	var sections []*Section
-	doc, err := goquery.NewDocumentFromReader(
-		strings.NewReader(response.Parse.Text.Value),
-	)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
 		return nil, err
 	}
+	if ignore != "" {
+		doc.Find(ignore).Remove()
+	}
 	// Create first section manually because its header is not included in the
 	// ".mw-parser-output"
 	var introBuilder strings.Builder
@@ -198,41 +492,3 @@ func (response *mediaWikiPageResponse) ParseSections() ([]*Section, error) {
 	})
 	return sections, err
 }
-
-// ParseSection parses the raw HTML of a mediaWikiPageResponse and searches for a section
-// that contains the heading specified by the function argument.
-// Returns a Section containing the heading and its corresponding body text.
-//
-// Can error when:
-//   - The content in the response is not valid HTML
-//   - The heading is not found.
-func (response *mediaWikiPageResponse) ParseSection(heading string) (*Section, error) {
-	var section Section
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(response.Parse.Text.Value))
-	if err != nil {
-		return nil, err
-	}
-	found := false
-	doc.Find("h2 > span.mw-headline").EachWithBreak(func(i int, h2 *goquery.Selection) bool {
-		if util.TrimLower(h2.Text()) == util.TrimLower(heading) {
-			section.Heading = h2.Text()
-			section.Index = i
-			var contentBuilder strings.Builder
-			h2.Parent().NextFilteredUntil("p", "h2 > span.mw-headline").Each(func(i int, s *goquery.Selection) {
-				contentBuilder.WriteString(s.Text())
-			})
-			section.Content = contentBuilder.String()
-			found = true
-			return false
-		}
-		return true
-	})
-
-	if !found {
-		return nil, &MediaWikiAPIError{
-			Code: "sectionnotfound",
-			Info: fmt.Sprintf("The section with heading %s was not found on page %s", heading, response.Parse.Title),
-		}
-	}
-	return &section, nil
-}
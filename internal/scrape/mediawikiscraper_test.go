@@ -0,0 +1,258 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+const testPageHTML = `<div class="mw-parser-output">` +
+	`<p>Bears are mammals.</p>` +
+	`<h2><span class="mw-headline" id="Etymology">Etymology</span></h2><p>Old English.</p>` +
+	`<h2><span class="mw-headline" id="Behaviour">Behaviour</span></h2><p>Bears sleep.</p>` +
+	`<h3><span class="mw-headline" id="Diet">Diet</span></h3><p>Bears eat.</p>` +
+	`</div>`
+
+const testSectionsJSON = `{"parse":{"title":"Bear","revid":7,"sections":[` +
+	`{"toclevel":1,"level":"2","line":"Etymology","number":"1","index":"1","fromtitle":"Bear","byteoffset":10,"anchor":"Etymology"},` +
+	`{"toclevel":1,"level":"2","line":"<i>Behaviour</i>","number":"2","index":"2","fromtitle":"Bear","byteoffset":50,"anchor":"Behaviour"},` +
+	`{"toclevel":2,"level":"3","line":"Diet","number":"2.1","index":"3","fromtitle":"Bear","byteoffset":90,"anchor":"Diet"}]}}`
+
+// newTestMediaWiki starts a fake MediaWiki API serving a single page "Bear",
+// recording the query of each request it receives.
+func newTestMediaWiki(t *testing.T, requests *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		*requests = append(*requests, r.URL.RawQuery)
+		if query.Get("page") != "Bear" && query.Get("oldid") != "7" {
+			fmt.Fprint(w, `{"error":{"code":"missingtitle","info":"The page you specified doesn't exist."}}`)
+			return
+		}
+		switch {
+		case query.Get("prop") == "sections|revid":
+			fmt.Fprint(w, testSectionsJSON)
+		case query.Get("section") == "2":
+			fmt.Fprint(w, `{"parse":{"title":"Bear","text":{"*":"<h2><span class=\"mw-headline\" id=\"Behaviour\">Behaviour</span></h2><p>Bears sleep.</p><h3><span class=\"mw-headline\" id=\"Diet\">Diet</span></h3><p>Bears eat.</p>"}}}`)
+		case query.Get("section") == "0":
+			fmt.Fprint(w, `{"parse":{"title":"Bear","text":{"*":"<p>Bears are mammals.</p>"}}}`)
+		default:
+			fmt.Fprintf(w, `{"parse":{"title":"Bear","text":{"*":%q}}}`, testPageHTML)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMediaWikiScraperGetPage(t *testing.T) {
+	var requests []string
+	server := newTestMediaWiki(t, &requests)
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	// Test 1: Valid page
+	page, err := scraper.GetPage("Bear")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(page.Sections) != 3 {
+		t.Fatalf("Section count mismatch. Got: %d, Want: %d", len(page.Sections), 3)
+	}
+	if page.Sections[2].Content != "Bears sleep.Bears eat." {
+		t.Errorf("Section content mismatch. Got: %s", page.Sections[2].Content)
+	}
+
+	// Test 2: Missing page
+	_, err = scraper.GetPage("Cheesebiscuit")
+	if _, ok := err.(*scrape.MediaWikiAPIError); !ok {
+		t.Errorf("Expected a MediaWikiAPIError for a missing page, got %v", err)
+	}
+}
+
+func TestMediaWikiScraperGetSections(t *testing.T) {
+	var requests []string
+	server := newTestMediaWiki(t, &requests)
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	// Test 1: A single section is fetched on its own, from the listed revision
+	filter, err := scrape.NewSectionFilter([]string{"behaviour"}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	page, err := scraper.GetSections("Bear", filter)
+	if err != nil {
+		t.Fatalf("Failed to get sections: %v", err)
+	}
+	want := scrape.Section{Heading: "Behaviour", Index: 2, Content: "Bears sleep.Bears eat."}
+	if len(page.Sections) != 1 || *page.Sections[0] != want {
+		t.Errorf("Section mismatch. Got: %+v, Want: %+v", page.Sections, want)
+	}
+	if len(requests) != 2 || !strings.Contains(requests[1], "oldid=7") || !strings.Contains(requests[1], "section=2") {
+		t.Errorf("Expected a section list request then a section request, got %v", requests)
+	}
+
+	// Test 2: Several sections are filtered from a single full page request
+	requests = nil
+	filter, err = scrape.NewSectionFilter([]string{"behaviour"}, []int{0}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	page, err = scraper.GetSections("Bear", filter)
+	if err != nil {
+		t.Fatalf("Failed to get sections: %v", err)
+	}
+	wantSections := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "Bears are mammals."},
+		{Heading: "Behaviour", Index: 2, Content: "Bears sleep.Bears eat."},
+	}
+	if len(page.Sections) != len(wantSections) {
+		t.Fatalf("Section count mismatch. Got: %d, Want: %d", len(page.Sections), len(wantSections))
+	}
+	for i, s := range page.Sections {
+		if *s != wantSections[i] {
+			t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, wantSections[i])
+		}
+	}
+	if len(requests) != 1 || strings.Contains(requests[0], "section") {
+		t.Errorf("Expected a single full page request, got %v", requests)
+	}
+
+	// Test 3: Exclusions alone need only the full page
+	requests = nil
+	filter, err = scrape.NewSectionFilter(nil, nil, nil, []string{"etymology"})
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	page, err = scraper.GetSections("Bear", filter)
+	if err != nil {
+		t.Fatalf("Failed to get sections: %v", err)
+	}
+	if len(page.Sections) != 2 || len(requests) != 1 {
+		t.Errorf("Expected 2 sections from 1 request, got %d sections from %v", len(page.Sections), requests)
+	}
+}
+
+func TestMediaWikiScraperGetTOC(t *testing.T) {
+	var requests []string
+	server := newTestMediaWiki(t, &requests)
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	toc, err := scraper.GetTOC("Bear")
+	if err != nil {
+		t.Fatalf("Failed to get table of contents: %v", err)
+	}
+	if toc.Title != "Bear" || len(toc.Entries) != 3 {
+		t.Fatalf("Unexpected table of contents: %+v", toc)
+	}
+	entry := toc.Entries[1]
+	if entry.Level != 1 || entry.Number != "2" || entry.Heading != "Behaviour" || entry.Anchor != "Behaviour" || *entry.ByteOffset != 50 {
+		t.Errorf("Unexpected table of contents entry: %+v", entry)
+	}
+	if toc.Entries[2].Level != 2 {
+		t.Errorf("Level mismatch for subsection. Got: %d, Want: 2", toc.Entries[2].Level)
+	}
+}
+
+func TestMediaWikiScraperRevisions(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		query := r.URL.Query()
+		if query.Get("oldid") != "123" && query.Get("pageid") != "456" {
+			fmt.Fprint(w, `{"error":{"code":"nosuchrevid","info":"There is no revision with ID 9."}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"parse":{"title":"Bear","text":{"*":%q}}}`, testPageHTML)
+	}))
+	defer server.Close()
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL}
+
+	// Test 1: Revisions and page ids are requested through Special:Redirect paths
+	for _, path := range []string{"Special:Redirect/revision/123", "Special:Redirect/page/456"} {
+		page, err := scraper.GetPage(path)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", path, err)
+		}
+		if page.Title != "Bear" || len(page.Sections) != 3 {
+			t.Errorf("Unexpected page for %s: %+v", path, page)
+		}
+	}
+	if len(requests) != 2 || strings.Contains(requests[0], "page=") {
+		t.Errorf("Unexpected requests: %v", requests)
+	}
+
+	// Test 2: Missing revision
+	_, err := scraper.GetPage("Special:Redirect/revision/9")
+	if e, ok := err.(*scrape.MediaWikiAPIError); !ok || e.Code != "nosuchrevid" {
+		t.Errorf("Expected a MediaWikiAPIError, got %v", err)
+	}
+}
+
+func TestMediaWikiScraperCache(t *testing.T) {
+	revision := 7
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		query := r.URL.Query()
+		switch {
+		case query.Get("prop") == "revisions":
+			fmt.Fprintf(w, `{"query":{"pages":[{"title":"Bear","revisions":[{"revid":%d}]}]}}`, revision)
+		case query.Has("oldid"):
+			fmt.Fprintf(w, `{"parse":{"title":"Bear","revid":%s,"text":{"*":"<p>Old.</p>"}}}`, query.Get("oldid"))
+		default:
+			fmt.Fprintf(w, `{"parse":{"title":"Bear","revid":%d,"text":{"*":"<p>Revision %d.</p>"}}}`, revision, revision)
+		}
+	}))
+	defer server.Close()
+	// Every entry is stale, so only revisions known to be current are served.
+	scraper := &scrape.MediaWikiScraper{BaseURL: server.URL, Cache: cache.New(t.TempDir(), 0)}
+	getPage := func(path string) *scrape.Page {
+		page, err := scraper.GetPage(path)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", path, err)
+		}
+		return page
+	}
+
+	// Test 1: A stale parse of the latest revision is revalidated, not fetched again
+	getPage("Bear")
+	requests = nil
+	if page := getPage("Bear"); page.Revision != 7 {
+		t.Errorf("Revision mismatch. Got: %d, Want: 7", page.Revision)
+	}
+	if len(requests) != 1 || !strings.Contains(requests[0], "prop=revisions") {
+		t.Errorf("Expected a single revision lookup, got %v", requests)
+	}
+
+	// Test 2: A parse of an older revision is fetched again
+	revision = 8
+	requests = nil
+	if page := getPage("Bear"); page.Revision != 8 || page.Sections[0].Content != "Revision 8." {
+		t.Errorf("Expected revision 8, got %+v", page)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected a revision lookup and a parse, got %v", requests)
+	}
+
+	// Test 3: Parses of a given revision never change
+	getPage("Special:Redirect/revision/3")
+	requests = nil
+	getPage("Special:Redirect/revision/3")
+	if len(requests) != 0 {
+		t.Errorf("Expected a cached revision, got %v", requests)
+	}
+
+	// Test 4: Revision lookups are never cached
+	for i := 0; i < 2; i++ {
+		if _, err := scraper.GetRevisionAt("Bear", time.Now()); err != nil {
+			t.Fatalf("Failed to get revision: %v", err)
+		}
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected two revision lookups, got %v", requests)
+	}
+}
@@ -0,0 +1,187 @@
+package scrape
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+// Wraps methods for retrieving and parsing pages through a MediaWiki REST
+// API, which serves Parsoid HTML with stable section markup. BaseURL is
+// either the Wikimedia REST API (ending in "/api/rest_v1"), which also
+// serves page summaries, or the core REST API of any MediaWiki (ending in
+// "/rest.php"). API responses are cached when Cache is not nil.
+type RESTScraper struct {
+	BaseURL string
+	Cache   *cache.Cache
+}
+
+// Representation of the json response returned by a Wikimedia REST API
+// page summary request.
+type restSummaryResponse struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Extract     string `json:"extract"`
+	Thumbnail   *struct {
+		Source string `json:"source"`
+	} `json:"thumbnail"`
+}
+
+// REST API error format. Wikimedia REST APIs describe errors with a
+// title and detail, the core REST API with an httpReason and messages.
+type RESTAPIError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted REST API error including code and
+// additional information.
+func (e *RESTAPIError) Error() string {
+	return fmt.Sprintf("REST API error: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// isWikimedia reports whether BaseURL points to a Wikimedia REST API
+// rather than the core MediaWiki REST API.
+func (s *RESTScraper) isWikimedia() bool {
+	return strings.HasSuffix(strings.TrimSuffix(s.BaseURL, "/"), "/rest_v1")
+}
+
+// endpoint builds the url of a page endpoint for the given path. Titles use
+// underscores in place of spaces and are path escaped.
+func (s *RESTScraper) endpoint(path string, kind string) (string, error) {
+	title, err := url.QueryUnescape(path)
+	if err != nil {
+		return "", err
+	}
+	title = url.PathEscape(strings.ReplaceAll(title, " ", "_"))
+	base := strings.TrimSuffix(s.BaseURL, "/")
+	if s.isWikimedia() {
+		return fmt.Sprintf("%s/page/%s/%s", base, kind, title), nil
+	}
+	return fmt.Sprintf("%s/v1/page/%s/%s", base, title, kind), nil
+}
+
+// fetch returns the body of a request to an endpoint, converting
+// unsuccessful responses into a RESTAPIError.
+func (s *RESTScraper) fetch(rawURL string) ([]byte, error) {
+	body, err := s.Cache.Fetch(http.DefaultClient, rawURL)
+	statusErr, ok := err.(*cache.StatusError)
+	if !ok {
+		return body, err
+	}
+	var details struct {
+		Title      string `json:"title"`
+		Detail     string `json:"detail"`
+		HTTPReason string `json:"httpReason"`
+	}
+	apiErr := &RESTAPIError{
+		Code: fmt.Sprintf("http%d", statusErr.StatusCode),
+		Info: http.StatusText(statusErr.StatusCode),
+	}
+	if json.Unmarshal(statusErr.Body, &details) == nil {
+		for _, info := range []string{details.Detail, details.Title, details.HTTPReason} {
+			if info != "" {
+				apiErr.Info = info
+				break
+			}
+		}
+	}
+	return nil, apiErr
+}
+
+// GetPage fetches the Parsoid HTML of the page specified by path and
+// parses it into sections.
+//
+// Can error when:
+//   - page fetch fails, for example when the page does not exist
+//   - section parsing fails
+func (s *RESTScraper) GetPage(path string) (*Page, error) {
+	endpoint, err := s.endpoint(path, "html")
+	if err != nil {
+		return nil, err
+	}
+	body, err := s.fetch(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return parseParsoidHTML(string(body))
+}
+
+// GetSections keeps the sections of the page specified by path selected by
+// the filter. The REST API only renders whole pages, so the page is always
+// fetched in full.
+func (s *RESTScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
+
+// GetSummary fetches the summary of the page specified by path: its lead
+// extract, description and thumbnail. Only available from Wikimedia REST APIs.
+func (s *RESTScraper) GetSummary(path string) (*Page, error) {
+	if !s.isWikimedia() {
+		return nil, &RESTAPIError{
+			Code: "summarynotsupported",
+			Info: "Page summaries are only served by Wikimedia REST APIs (api/rest_v1)",
+		}
+	}
+	endpoint, err := s.endpoint(path, "summary")
+	if err != nil {
+		return nil, err
+	}
+	body, err := s.fetch(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var summary restSummaryResponse
+	if err := json.Unmarshal(body, &summary); err != nil {
+		return nil, err
+	}
+	page := &Page{
+		Title:       summary.Title,
+		Description: summary.Description,
+		Sections: []*Section{{
+			Heading: "Introduction",
+			Index:   0,
+			Content: summary.Extract,
+		}},
+	}
+	if summary.Thumbnail != nil {
+		page.Thumbnail = summary.Thumbnail.Source
+	}
+	return page, nil
+}
+
+// parseParsoidHTML parses a Parsoid HTML document into sections. Parsoid
+// wraps each section in a <section data-mw-section-id> element, nesting
+// subsections within their parent, so the introduction is the section with
+// id 0 and every other top level section starts with its heading.
+func parseParsoidHTML(html string) (*Page, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{Title: strings.TrimSpace(doc.Find("head > title").Text())}
+	doc.Find("body > section[data-mw-section-id]").Each(func(i int, section *goquery.Selection) {
+		heading := "Introduction"
+		if id, _ := section.Attr("data-mw-section-id"); id != "0" {
+			h := section.ChildrenFiltered("h1, h2, h3, h4, h5, h6").First()
+			if h.Length() == 0 {
+				return
+			}
+			heading = strings.TrimSpace(h.Text())
+		}
+		var contentBuilder strings.Builder
+		section.Find("p").Each(func(_ int, p *goquery.Selection) {
+			contentBuilder.WriteString(p.Text())
+		})
+		page.Sections = append(page.Sections, &Section{
+			Heading: heading,
+			Index:   len(page.Sections),
+			Content: contentBuilder.String(),
+		})
+	})
+	return page, nil
+}
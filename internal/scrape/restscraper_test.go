@@ -0,0 +1,95 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testParsoidHTML = `<!DOCTYPE html><html><head><title>Brown bear</title></head><body>` +
+	`<section data-mw-section-id="0"><p>The brown bear is a bear.</p></section>` +
+	`<section data-mw-section-id="1"><h2 id="Taxonomy">Taxonomy</h2><p>Ursus arctos.</p>` +
+	`<section data-mw-section-id="2"><h3 id="Subspecies">Subspecies</h3><p>Many.</p></section></section>` +
+	`<section data-mw-section-id="3"><h2 id="Range">Range</h2><p>Eurasia.</p></section>` +
+	`</body></html>`
+
+// newTestREST starts a fake REST API serving "Brown bear" under both the
+// Wikimedia (/api/rest_v1) and core (/rest.php) endpoint layouts.
+func newTestREST(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/rest_v1/page/html/Brown_bear", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testParsoidHTML)
+	})
+	mux.HandleFunc("/rest.php/v1/page/Brown_bear/html", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testParsoidHTML)
+	})
+	mux.HandleFunc("/api/rest_v1/page/summary/Brown_bear", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"title":"Brown bear","description":"Large North American and Eurasian bear",`+
+			`"extract":"The brown bear is a bear.","thumbnail":{"source":"https://upload.example/bear.jpg"}}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"type":"not_found","title":"Not found.","detail":"Page or revision not found."}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRESTScraperGetPage(t *testing.T) {
+	server := newTestREST(t)
+	for _, base := range []string{"/api/rest_v1", "/rest.php"} {
+		t.Run(base, func(t *testing.T) {
+			scraper := &scrape.RESTScraper{BaseURL: server.URL + base}
+			page, err := scraper.GetPage("Brown bear")
+			if err != nil {
+				t.Fatalf("Failed to get page: %v", err)
+			}
+			want := []scrape.Section{
+				{Heading: "Introduction", Index: 0, Content: "The brown bear is a bear."},
+				{Heading: "Taxonomy", Index: 1, Content: "Ursus arctos.Many."},
+				{Heading: "Range", Index: 2, Content: "Eurasia."},
+			}
+			if page.Title != "Brown bear" || len(page.Sections) != len(want) {
+				t.Fatalf("Unexpected page: %+v", page)
+			}
+			for i, s := range page.Sections {
+				if *s != want[i] {
+					t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRESTScraperGetSummary(t *testing.T) {
+	server := newTestREST(t)
+
+	// Test 1: Wikimedia summary
+	scraper := &scrape.RESTScraper{BaseURL: server.URL + "/api/rest_v1"}
+	page, err := scraper.GetSummary("Brown_bear")
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err)
+	}
+	if page.Description != "Large North American and Eurasian bear" || page.Thumbnail != "https://upload.example/bear.jpg" ||
+		page.Sections[0].Content != "The brown bear is a bear." {
+		t.Errorf("Unexpected summary: %+v", page)
+	}
+
+	// Test 2: Missing page
+	_, err = scraper.GetSummary("Cheesebiscuit")
+	apiErr, ok := err.(*scrape.RESTAPIError)
+	if !ok || apiErr.Code != "http404" || apiErr.Info != "Page or revision not found." {
+		t.Errorf("Expected a not found RESTAPIError, got %v", err)
+	}
+
+	// Test 3: Core REST API has no summaries
+	scraper = &scrape.RESTScraper{BaseURL: server.URL + "/rest.php"}
+	_, err = scraper.GetSummary("Brown bear")
+	if err == nil {
+		t.Error("Expected an error for a summary from the core REST API, but got nil")
+	}
+}
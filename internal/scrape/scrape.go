@@ -5,31 +5,218 @@
 // Currently supported API backends: see 'wikiscrape list backends'
 package scrape
 
+import "time"
+
 // Page represents a wiki/backend agnostic container for storing the content
-// of a wiki page.
+// of a wiki page. Description and Thumbnail (an image url) are only set by
+// backends which provide page summaries, Infobox only by backends which
+// parse infoboxes. Revision is the id of the revision the page was read
+// from, for backends which track revisions.
 type Page struct {
-	Title    string
-	Sections []*Section
+	Title       string
+	Revision    int
+	Description string
+	Thumbnail   string
+	Infobox     []*InfoboxField
+	Sections    []*Section
+	// Filter is the filter that selected Sections, nil when every section
+	// of the page was scraped.
+	Filter *SectionFilter
+}
+
+// InfoboxField is a single labelled value from the infobox of a page.
+// Group is the heading of the infobox group holding the field, if any.
+type InfoboxField struct {
+	Group string `json:"group,omitempty"`
+	Label string `json:"label"`
+	Value string `json:"value"`
 }
 
 // Section represents a wiki/backend agnostic container for storing the contents
 // of a single section of a wiki page.
 type Section struct {
-	Heading string
-	Index   int
-	Content string
+	Heading string `json:"heading"`
+	Index   int    `json:"index"`
+	Content string `json:"content"`
 }
 
 // Response denotes the methods one should implement on the API
 // response struct for a specific wiki.
 type Response interface {
-	ParseSections() ([]Section, error)
-	ParseSection(heading string) (Section, error)
+	ParseSections() ([]*Section, error)
 }
 
 // Scraper denotes the methods one should implement on the scraper
 // struct for a specific wiki in order to handle requests.
 type Scraper interface {
 	GetPage(path string) (*Page, error)
-	GetSection(path string, heading string) (*Page, error)
+	GetSections(path string, filter *SectionFilter) (*Page, error)
+}
+
+// TOC represents the table of contents of a wiki page.
+type TOC struct {
+	Title   string      `json:"title"`
+	Entries []*TOCEntry `json:"entries"`
+}
+
+// TOCEntry represents a single heading in the table of contents of a
+// wiki page. ByteOffset is the position of the heading in the page
+// source, and is nil for headings transcluded from other pages.
+type TOCEntry struct {
+	Level      int    `json:"level"`
+	Number     string `json:"number"`
+	Heading    string `json:"heading"`
+	Anchor     string `json:"anchor"`
+	ByteOffset *int   `json:"byteOffset"`
+}
+
+// TOCScraper is implemented by scrapers that can list the sections of
+// a page without retrieving its content.
+type TOCScraper interface {
+	GetTOC(path string) (*TOC, error)
+}
+
+// SearchResult represents a single page returned by a wiki search.
+type SearchResult struct {
+	Title     string `json:"title"`
+	Snippet   string `json:"snippet"`
+	Size      int    `json:"size"`
+	WordCount int    `json:"wordCount"`
+	Timestamp string `json:"timestamp"`
+}
+
+// SearchOptions narrows a wiki search. A Limit of 0 or less uses the
+// backend's default, and an empty Namespaces searches the main namespace.
+type SearchOptions struct {
+	Limit      int
+	Namespaces []int
+}
+
+// Searcher is implemented by scrapers that can search a wiki for pages.
+type Searcher interface {
+	Search(query string, opts *SearchOptions) ([]*SearchResult, error)
+}
+
+// CategoryOptions controls how a category is walked. Depth is the number
+// of levels of subcategories to descend into, 0 lists only the direct
+// members. An empty Namespaces selects pages in the main namespace.
+type CategoryOptions struct {
+	Depth      int
+	Namespaces []int
+}
+
+// CategoryLister is implemented by scrapers that can list the pages
+// belonging to a category.
+type CategoryLister interface {
+	GetCategoryMembers(category string, opts *CategoryOptions) ([]string, error)
+}
+
+// LinkLister is implemented by scrapers that can list the internal links
+// of a page. GetLinks returns the canonical title of the page, after
+// following redirects, along with the titles it links to in the requested
+// namespaces (the main namespace when none are given).
+type LinkLister interface {
+	GetLinks(path string, namespaces []int) (string, []string, error)
+}
+
+// BatchScraper is implemented by scrapers that can retrieve several pages
+// per request. GetPages returns the pages that exist, once each in the order
+// they were requested, and the requested paths which resolved to no page.
+type BatchScraper interface {
+	GetPages(paths []string) ([]*Page, []string, error)
+	BatchSize() int
+}
+
+// Summarizer is implemented by scrapers that can retrieve a short summary
+// of a page: its title, description, thumbnail and the lead section extract
+// as the page's only section.
+type Summarizer interface {
+	GetSummary(path string) (*Page, error)
+}
+
+// RevisionResolver is implemented by scrapers that can retrieve past
+// revisions of a page. GetRevisionAt returns the id of the revision of the
+// page that was current at the given time. Passing a path returned by
+// RevisionPath to GetPage or GetSections retrieves that revision.
+type RevisionResolver interface {
+	GetRevisionAt(path string, at time.Time) (int, error)
+	RevisionPath(revision int) string
+}
+
+// Revision represents a single edit in the history of a page. SizeDelta is
+// the change in the page's size in bytes made by the edit.
+type Revision struct {
+	ID        int      `json:"id"`
+	Timestamp string   `json:"timestamp"`
+	User      string   `json:"user"`
+	Comment   string   `json:"comment"`
+	Size      int      `json:"size"`
+	SizeDelta int      `json:"sizeDelta"`
+	Minor     bool     `json:"minor"`
+	Tags      []string `json:"tags"`
+}
+
+// HistoryOptions narrows the history of a page to the revisions made
+// between Since and Until, either of which may be zero to leave that end
+// open. A Limit of 0 or less lists every matching revision.
+type HistoryOptions struct {
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// HistoryLister is implemented by scrapers that can list the revisions of
+// a page, newest first.
+type HistoryLister interface {
+	GetHistory(path string, opts *HistoryOptions) ([]*Revision, error)
+}
+
+// PageState is the current state of a page previously scraped as Title.
+// CurrentTitle is its canonical title, which differs from Title when the
+// page has since been moved, leaving a redirect behind. Missing is set when
+// the page no longer exists.
+type PageState struct {
+	Title        string
+	CurrentTitle string
+	Revision     int
+	Missing      bool
+}
+
+// RevisionChecker is implemented by scrapers that can look up the latest
+// revision of many pages in few requests. GetLatestRevisions returns the
+// state of every page, in the order requested.
+type RevisionChecker interface {
+	GetLatestRevisions(paths []string) ([]*PageState, error)
+}
+
+// RecentChange is a single entry of a wiki's recent changes: an edit,
+// creation, move or deletion of a page, given by Type as "edit", "new",
+// "move" or "delete". NewTitle is only set for moves, and Revision is the
+// id of the revision made, for edits and creations.
+type RecentChange struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	NewTitle  string `json:"newTitle,omitempty"`
+	Revision  int    `json:"revision,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// RecentChangesOptions selects the recent changes made at or after Since
+// to pages in Namespaces (the main namespace when empty).
+type RecentChangesOptions struct {
+	Since      time.Time
+	Namespaces []int
+}
+
+// RecentChangesLister is implemented by scrapers that can list the recent
+// changes made to a wiki, oldest first.
+type RecentChangesLister interface {
+	GetRecentChanges(opts *RecentChangesOptions) ([]*RecentChange, error)
+}
+
+// PageLister is implemented by scrapers that can list every page of a
+// wiki, such as those reading a whole wiki from a single file.
+type PageLister interface {
+	ListPages() ([]string, error)
 }
@@ -0,0 +1,106 @@
+package scrape
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	tiddlyHeading   = regexp.MustCompile(`^(!{1,6})\s*(.*?)\s*$`)
+	tiddlyBlock     = regexp.MustCompile("^(```|<<<|\\$\\$\\$)")
+	tiddlyLink      = regexp.MustCompile(`\[(?:ext|img)?\[([^\]|]*)(?:\|[^\]]*)?\]\]`)
+	tiddlyImage     = regexp.MustCompile(`\[img(?:\s[^\[]*)?\[[^\]]*\]\]`)
+	tiddlyMacro     = regexp.MustCompile(`(?s)<<.*?>>`)
+	tiddlyFormat    = regexp.MustCompile("''|__|~~|\\^\\^|,,|`|@@(?:[\\w-]+:[^;]*;|\\.[\\w-]+)*")
+	tiddlyCamelCase = regexp.MustCompile(`~([A-Z][a-z]+[A-Z]\w*)`)
+	// Italics, but not the slashes of a url scheme
+	tiddlyItalic = regexp.MustCompile(`(^|[^:])//`)
+)
+
+// ParseTiddlyWikiText splits the WikiText of a tiddler into a Page, following
+// the same rules as ParseMarkdown: headings ("!", "!!", ...) form the
+// sections, a lone opening top level heading becomes the page title and
+// deeper headings are folded into their parent section. Only paragraph text
+// is kept; code blocks, block quotes, lists, tables, macros and transclusions
+// are discarded and links are reduced to their text.
+func ParseTiddlyWikiText(text string) *Page {
+	var intro string
+	var headings []htmlHeading
+	var body strings.Builder
+	flush := func() {
+		content := tiddlyToText(body.String())
+		body.Reset()
+		if len(headings) == 0 {
+			intro = content
+			return
+		}
+		headings[len(headings)-1].Content = content
+	}
+	text = wikitextComment.ReplaceAllString(strings.ReplaceAll(text, "\r\n", "\n"), "")
+	block := ""
+	for _, line := range strings.Split(text, "\n") {
+		if block != "" {
+			if strings.HasPrefix(line, block) {
+				block = ""
+			}
+			continue
+		}
+		if m := tiddlyBlock.FindStringSubmatch(line); m != nil {
+			block = m[1]
+			body.WriteByte('\n')
+			continue
+		}
+		if m := tiddlyHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			headings = append(headings, htmlHeading{
+				Level: len(m[1]),
+				Text:  strings.TrimSpace(tiddlyInline(m[2])),
+			})
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+	return foldHeadings(intro, headings)
+}
+
+// tiddlyInline strips inline WikiText formatting from a line of text.
+func tiddlyInline(text string) string {
+	text = tiddlyImage.ReplaceAllString(text, "")
+	text = tiddlyLink.ReplaceAllString(text, "$1")
+	text = tiddlyMacro.ReplaceAllString(text, "")
+	text = removeNested(text, "{{", "}}")
+	text = tiddlyFormat.ReplaceAllString(text, "")
+	text = tiddlyItalic.ReplaceAllString(text, "$1")
+	text = tiddlyCamelCase.ReplaceAllString(text, "$1")
+	text = wikitextTag.ReplaceAllString(text, "")
+	return html.UnescapeString(text)
+}
+
+// tiddlyToText reduces a fragment of WikiText to its paragraph text.
+// Each paragraph is terminated by a newline.
+func tiddlyToText(text string) string {
+	var out strings.Builder
+	var paragraph []string
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString(strings.Join(paragraph, " "))
+			out.WriteByte('\n')
+			paragraph = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.ContainsAny(trimmed[:1], "*#;:>|") || strings.HasPrefix(trimmed, "---") || strings.HasPrefix(trimmed, "\\") {
+			endParagraph()
+			continue
+		}
+		if line := strings.TrimSpace(tiddlyInline(line)); line != "" {
+			paragraph = append(paragraph, line)
+		}
+	}
+	endParagraph()
+	return out.String()
+}
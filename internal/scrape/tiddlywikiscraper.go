@@ -0,0 +1,257 @@
+package scrape
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+// Wraps methods for retrieving and parsing tiddlers from a TiddlyWiki. Path
+// is either a single file TiddlyWiki (an .html file) or the url of a
+// TiddlyWeb compatible server, such as the TiddlyWiki Node.js server. Each
+// tiddler is treated as a page. Server responses are cached when Cache is
+// not nil.
+type TiddlyWikiScraper struct {
+	Path  string
+	Cache *cache.Cache
+	store map[string]*tiddler
+}
+
+// A tiddler as stored in a TiddlyWiki file or served by TiddlyWeb. Only the
+// fields needed to parse its content are kept.
+type tiddler struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	Type  string `json:"type"`
+}
+
+// TiddlyWikiError indicates that a tiddler could not be retrieved or read.
+type TiddlyWikiError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted TiddlyWiki error including code and
+// additional information.
+func (e *TiddlyWikiError) Error() string {
+	return fmt.Sprintf("TiddlyWiki error: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// isServer reports whether Path is the url of a TiddlyWeb server rather
+// than a local file.
+func (s *TiddlyWikiScraper) isServer() bool {
+	return strings.HasPrefix(s.Path, "http://") || strings.HasPrefix(s.Path, "https://")
+}
+
+// loadStore reads every tiddler from the TiddlyWiki file, once. Tiddlers are
+// read from the JSON tiddler stores of TiddlyWiki 5.2 and later, and from the
+// store area of older versions, including TiddlyWiki Classic.
+func (s *TiddlyWikiScraper) loadStore() error {
+	if s.store != nil {
+		return nil
+	}
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		return err
+	}
+	store := map[string]*tiddler{}
+	doc.Find("#storeArea > div[title]").Each(func(_ int, div *goquery.Selection) {
+		t := &tiddler{Title: div.AttrOr("title", ""), Type: div.AttrOr("type", "")}
+		if pre := div.Find("pre"); pre.Length() > 0 {
+			t.Text = pre.First().Text()
+		} else {
+			t.Text = div.Text()
+		}
+		store[t.Title] = t
+	})
+	doc.Find("script.tiddlywiki-tiddler-store").EachWithBreak(func(_ int, script *goquery.Selection) bool {
+		var tiddlers []*tiddler
+		if err = json.Unmarshal([]byte(script.Text()), &tiddlers); err != nil {
+			return false
+		}
+		for _, t := range tiddlers {
+			store[t.Title] = t
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	s.store = store
+	return nil
+}
+
+// fetch makes a request to the TiddlyWeb server and unmarshals the json
+// response into result.
+func (s *TiddlyWikiScraper) fetch(endpoint string, result any) error {
+	reqURL := strings.TrimSuffix(s.Path, "/") + "/recipes/default/" + endpoint
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	body, err := s.Cache.Do(http.DefaultClient, req, nil)
+	if statusErr, ok := err.(*cache.StatusError); ok {
+		return &TiddlyWikiError{
+			Code: fmt.Sprintf("http%d", statusErr.StatusCode),
+			Info: fmt.Sprintf("The server responded to %s: %s", reqURL, http.StatusText(statusErr.StatusCode)),
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, result)
+}
+
+// findTiddler returns the tiddler titled path. Titles are matched exactly,
+// then ignoring case.
+func (s *TiddlyWikiScraper) findTiddler(path string) (*tiddler, error) {
+	title, err := url.QueryUnescape(path)
+	if err != nil {
+		return nil, err
+	}
+	notFound := &TiddlyWikiError{
+		Code: "tiddlernotfound",
+		Info: fmt.Sprintf("No tiddler titled %s was found in %s", title, s.Path),
+	}
+	if s.isServer() {
+		var t tiddler
+		err := s.fetch("tiddlers/"+url.PathEscape(title), &t)
+		if e, ok := err.(*TiddlyWikiError); ok && e.Code == "http404" {
+			return nil, notFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &t, nil
+	}
+	if err := s.loadStore(); err != nil {
+		return nil, err
+	}
+	if t, ok := s.store[title]; ok {
+		return t, nil
+	}
+	for name, t := range s.store {
+		if strings.EqualFold(name, title) {
+			return t, nil
+		}
+	}
+	return nil, notFound
+}
+
+// parseTiddler parses the text of a tiddler according to its content type.
+func parseTiddler(t *tiddler) (*Page, error) {
+	var page *Page
+	switch t.Type {
+	case "", "text/vnd.tiddlywiki", "text/x-tiddlywiki":
+		page = ParseTiddlyWikiText(t.Text)
+	case "text/x-markdown", "text/markdown":
+		page = ParseMarkdown(t.Text)
+	case "text/html":
+		var err error
+		page, err = parseHeadingHTML(t.Text, "")
+		if err != nil {
+			return nil, err
+		}
+	case "text/plain":
+		page = &Page{Sections: []*Section{{Heading: "Introduction", Index: 0, Content: t.Text}}}
+	default:
+		return nil, &TiddlyWikiError{
+			Code: "unsupportedtype",
+			Info: fmt.Sprintf("The tiddler %s has content type %s, which cannot be parsed into sections", t.Title, t.Type),
+		}
+	}
+	page.Title = t.Title
+	return page, nil
+}
+
+// GetPage finds the tiddler titled path and parses its text into sections.
+//
+// Can error when:
+//   - The file cannot be read or the server request fails
+//   - There is no tiddler with the given title
+//   - The tiddler does not hold text, such as an image
+func (s *TiddlyWikiScraper) GetPage(path string) (*Page, error) {
+	t, err := s.findTiddler(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseTiddler(t)
+}
+
+// GetSections keeps the sections of the tiddler specified by path selected
+// by the filter, after reading the whole tiddler.
+func (s *TiddlyWikiScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
+
+// BatchSize returns the number of tiddlers fetched per batch. A TiddlyWiki
+// file is read whole, so batches are only limited to keep progress visible.
+func (s *TiddlyWikiScraper) BatchSize() int {
+	return 1000
+}
+
+// GetPages returns the tiddlers titled by paths, once each, and the
+// titles of those which do not exist or do not hold text.
+func (s *TiddlyWikiScraper) GetPages(paths []string) ([]*Page, []string, error) {
+	var pages []*Page
+	var missing []string
+	seen := map[string]bool{}
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		page, err := s.GetPage(path)
+		if _, ok := err.(*TiddlyWikiError); ok {
+			missing = append(missing, path)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, missing, nil
+}
+
+// ListPages returns the titles of every tiddler, sorted, excluding system
+// tiddlers (whose titles start with "$:/") and drafts.
+func (s *TiddlyWikiScraper) ListPages() ([]string, error) {
+	var titles []string
+	if s.isServer() {
+		var tiddlers []*tiddler
+		if err := s.fetch("tiddlers.json", &tiddlers); err != nil {
+			return nil, err
+		}
+		for _, t := range tiddlers {
+			titles = append(titles, t.Title)
+		}
+	} else {
+		if err := s.loadStore(); err != nil {
+			return nil, err
+		}
+		for title := range s.store {
+			titles = append(titles, title)
+		}
+	}
+	pages := titles[:0]
+	for _, title := range titles {
+		if !strings.HasPrefix(title, "$:/") && !strings.HasPrefix(title, "Draft of '") {
+			pages = append(pages, title)
+		}
+	}
+	sort.Strings(pages)
+	return pages, nil
+}
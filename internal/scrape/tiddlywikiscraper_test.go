@@ -0,0 +1,135 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testTiddlerText = `Our ''deploy'' guide, see [[Rollback|Rolling back]] and https://example.com/ci.
+<<list-links filter:"[tag[ops]]">>
+! Install
+Run the //installer//.{{Shared notes}}
+* not a paragraph
+!! Linux
+Use ~CamelCase apt.
+` + "```" + `
+! not a heading
+` + "```" + `
+! Contacts
+Ask the team.`
+
+const testTiddlyWikiHTML = `<!doctype html><html><body>
+<script class="tiddlywiki-tiddler-store" type="application/json">[
+{"title":"Deploying","text":%s},
+{"title":"Notes","type":"text/x-markdown","text":"Some notes.\n# Usage\nRead them."},
+{"title":"Logo","type":"image/png","text":"iVBORw0KGgo="},
+{"title":"$:/SiteTitle","text":"My Wiki"}
+]</script>
+<div id="storeArea" style="display:none;"><div title="Legacy" type="text/vnd.tiddlywiki"><pre>Kept &amp; restored.</pre></div></div>
+</body></html>`
+
+func TestParseTiddlyWikiText(t *testing.T) {
+	page := scrape.ParseTiddlyWikiText(testTiddlerText)
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "Our deploy guide, see Rollback and https://example.com/ci.\n"},
+		{Heading: "Install", Index: 1, Content: "Run the installer.\nUse CamelCase apt.\n"},
+		{Heading: "Contacts", Index: 2, Content: "Ask the team.\n"},
+	}
+	if len(page.Sections) != len(want) {
+		t.Fatalf("Section count mismatch. Got: %d, Want: %d", len(page.Sections), len(want))
+	}
+	for i, s := range page.Sections {
+		if *s != want[i] {
+			t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, want[i])
+		}
+	}
+}
+
+func TestTiddlyWikiScraper(t *testing.T) {
+	text := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(testTiddlerText)
+	path := filepath.Join(t.TempDir(), "wiki.html")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(testTiddlyWikiHTML, `"`+text+`"`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scraper := &scrape.TiddlyWikiScraper{Path: path}
+
+	// Test 1: Tiddlers from the JSON store and store area, by content type
+	for title, want := range map[string]string{
+		"deploying": "Our deploy guide, see Rollback and https://example.com/ci.\n",
+		"Notes":     "Some notes.\n",
+		"Legacy":    "Kept & restored.\n",
+	} {
+		page, err := scraper.GetPage(title)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", title, err)
+		}
+		if page.Sections[0].Content != want {
+			t.Errorf("Introduction mismatch for %s. Got: %q, Want: %q", title, page.Sections[0].Content, want)
+		}
+	}
+
+	// Test 2: Non text and missing tiddlers
+	for _, title := range []string{"Logo", "Missing"} {
+		if _, err := scraper.GetPage(title); err == nil {
+			t.Errorf("Expected an error for %s, got nil", title)
+		}
+	}
+
+	// Test 3: Listing skips system tiddlers
+	titles, err := scraper.ListPages()
+	if err != nil {
+		t.Fatalf("Failed to list pages: %v", err)
+	}
+	if strings.Join(titles, ",") != "Deploying,Legacy,Logo,Notes" {
+		t.Errorf("Unexpected titles: %v", titles)
+	}
+
+	// Test 4: Batches omit tiddlers which cannot be read
+	pages, missing, err := scraper.GetPages(append(titles, "Notes"))
+	if err != nil || len(pages) != 3 || len(missing) != 1 {
+		t.Errorf("Unexpected batch: %v, missing %v, %v", pages, missing, err)
+	}
+}
+
+func TestTiddlyWikiScraperServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/recipes/default/tiddlers.json":
+			fmt.Fprint(w, `[{"title":"Getting Started"},{"title":"$:/StoryList"}]`)
+		case "/recipes/default/tiddlers/Getting Started":
+			fmt.Fprint(w, `{"title":"Getting Started","type":"text/vnd.tiddlywiki","text":"Welcome.\n! Next\nRead on."}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	scraper := &scrape.TiddlyWikiScraper{Path: server.URL}
+
+	// Test 1: Tiddler by title
+	page, err := scraper.GetPage("Getting%20Started")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if page.Title != "Getting Started" || len(page.Sections) != 2 || page.Sections[1].Content != "Read on.\n" {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+
+	// Test 2: Missing tiddler
+	_, err = scraper.GetPage("Missing")
+	if e, ok := err.(*scrape.TiddlyWikiError); !ok || e.Code != "tiddlernotfound" {
+		t.Errorf("Expected a TiddlyWikiError, got %v", err)
+	}
+
+	// Test 3: Listing
+	titles, err := scraper.ListPages()
+	if err != nil || len(titles) != 1 || titles[0] != "Getting Started" {
+		t.Errorf("Unexpected titles: %v, %v", titles, err)
+	}
+}
@@ -0,0 +1,174 @@
+package scrape
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+)
+
+// GraphQL query for a single Wiki.js page by its path.
+const wikiJSPageQuery = `query ($path: String!, $locale: String!) {
+  pages {
+    singleByPath(path: $path, locale: $locale) {
+      title
+      description
+      contentType
+      content
+      render
+    }
+  }
+}`
+
+// Wraps methods for retrieving and parsing pages on a Wiki.js (2.x) based
+// website through its GraphQL API. BaseURL is the wiki's GraphQL endpoint,
+// usually "https://<host>/graphql". Token is an API key, sent as a bearer
+// token, which is required unless guests may read the wiki. Locale selects
+// the page locale and defaults to "en". API responses are cached when
+// Cache is not nil.
+type WikiJSScraper struct {
+	BaseURL string
+	Token   string
+	Locale  string
+	Cache   *cache.Cache
+}
+
+// Representation of the json response returned by the Wiki.js GraphQL
+// API for a page query.
+type wikiJSPageResponse struct {
+	Data struct {
+		Pages struct {
+			SingleByPath *struct {
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				ContentType string `json:"contentType"`
+				Content     string `json:"content"`
+				Render      string `json:"render"`
+			} `json:"singleByPath"`
+		} `json:"pages"`
+	} `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Exception struct {
+				Code int `json:"code"`
+			} `json:"exception"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// WikiJSError indicates that a Wiki.js page could not be retrieved, either
+// because the request failed or the GraphQL API reported an error.
+type WikiJSError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted Wiki.js error including code and
+// additional information.
+func (e *WikiJSError) Error() string {
+	return fmt.Sprintf("Wiki.js error: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// query posts a GraphQL query with the given variables and unmarshals the
+// json response into result. Responses holding GraphQL errors are never
+// cached.
+func (s *WikiJSScraper) query(query string, variables map[string]any, result *wikiJSPageResponse) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.BaseURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resBody, err := s.Cache.Do(http.DefaultClient, req, body)
+	if statusErr, ok := err.(*cache.StatusError); ok {
+		return &WikiJSError{
+			Code: fmt.Sprintf("http%d", statusErr.StatusCode),
+			Info: fmt.Sprintf("The GraphQL API at %s responded: %s", s.BaseURL, http.StatusText(statusErr.StatusCode)),
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(resBody, result); err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		if s.Cache != nil {
+			s.Cache.Remove(s.BaseURL, body, cache.Identity(req))
+		}
+		code := "graphqlerror"
+		if c := result.Errors[0].Extensions.Exception.Code; c != 0 {
+			code = fmt.Sprintf("wikijs%d", c)
+		}
+		return &WikiJSError{Code: code, Info: result.Errors[0].Message}
+	}
+	return nil
+}
+
+// GetPage fetches the page specified by path and parses its rendered HTML
+// into sections. Pages which have not been rendered are parsed from their
+// stored Markdown or HTML source instead.
+//
+// Can error when:
+//   - page fetch fails, for example when the page does not exist or the
+//     token does not grant read access
+//   - section parsing fails
+func (s *WikiJSScraper) GetPage(path string) (*Page, error) {
+	pagePath, err := url.QueryUnescape(path)
+	if err != nil {
+		return nil, err
+	}
+	pagePath = strings.Trim(strings.TrimSpace(pagePath), "/")
+	locale := s.Locale
+	if locale == "" {
+		locale = "en"
+	}
+	var result wikiJSPageResponse
+	variables := map[string]any{"path": pagePath, "locale": locale}
+	if err := s.query(wikiJSPageQuery, variables, &result); err != nil {
+		return nil, err
+	}
+	data := result.Data.Pages.SingleByPath
+	if data == nil {
+		return nil, &WikiJSError{
+			Code: "pagenotfound",
+			Info: fmt.Sprintf("The page %s does not exist in locale %s", pagePath, locale),
+		}
+	}
+	var page *Page
+	switch {
+	case data.Render != "":
+		// Skip the permalink anchors Wiki.js adds to each heading.
+		page, err = parseHeadingHTML(data.Render, "a.toc-anchor")
+	case data.ContentType == "html":
+		page, err = parseHeadingHTML(data.Content, "")
+	default:
+		page = ParseMarkdown(data.Content)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Wiki.js keeps the title apart from the content, which usually does
+	// not repeat it.
+	if data.Title != "" {
+		page.Title = data.Title
+	}
+	page.Description = data.Description
+	return page, nil
+}
+
+// GetSections keeps the sections of the page specified by path selected by
+// the filter. The GraphQL API returns a page with all of its content, so
+// the page is always fetched in full.
+func (s *WikiJSScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
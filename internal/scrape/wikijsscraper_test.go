@@ -0,0 +1,95 @@
+package scrape_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testWikiJSRender = `<h1 class="toc-header" id="install"><a href="#install" class="toc-anchor">¶</a> Install</h1>
+<p>Run the installer.</p>
+<h2 class="toc-header" id="linux"><a href="#linux" class="toc-anchor">¶</a> Linux</h2>
+<p>Use apt.</p>
+<h1 class="toc-header" id="rollback"><a href="#rollback" class="toc-anchor">¶</a> Rollback</h1>
+<p>Revert.</p>`
+
+func TestWikiJSScraper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var request struct {
+			Variables struct {
+				Path   string `json:"path"`
+				Locale string `json:"locale"`
+			} `json:"variables"`
+		}
+		if err := jsoniter.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		page := map[string]any{"title": "Deployment", "description": "How we deploy"}
+		switch request.Variables.Path {
+		case "ops/deployment":
+			page["contentType"] = "markdown"
+			page["render"] = testWikiJSRender
+		case "ops/draft":
+			page["contentType"] = "markdown"
+			page["content"] = "Draft intro.\n\n# Install\nRun it.\n# Rollback\nRevert.\n"
+		default:
+			fmt.Fprint(w, `{"errors":[{"message":"This page does not exist.","extensions":{"exception":{"code":6003}}}],"data":{"pages":{"singleByPath":null}}}`)
+			return
+		}
+		if request.Variables.Locale != "en" {
+			t.Errorf("Locale mismatch. Got: %s, Want: en", request.Variables.Locale)
+		}
+		jsoniter.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"pages": map[string]any{"singleByPath": page}}})
+	}))
+	defer server.Close()
+	scraper := &scrape.WikiJSScraper{BaseURL: server.URL + "/graphql", Token: "secret"}
+
+	// Test 1: Rendered page, with permalink anchors removed
+	page, err := scraper.GetPage("/ops/deployment")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0},
+		{Heading: "Install", Index: 1, Content: "Run the installer.Use apt."},
+		{Heading: "Rollback", Index: 2, Content: "Revert."},
+	}
+	if page.Title != "Deployment" || page.Description != "How we deploy" || len(page.Sections) != len(want) {
+		t.Fatalf("Unexpected page: %+v", page)
+	}
+	for i, s := range page.Sections {
+		if *s != want[i] {
+			t.Errorf("Section mismatch at index %d. Got: %+v, Want: %+v", i, *s, want[i])
+		}
+	}
+
+	// Test 2: Unrendered page parsed from its Markdown source
+	page, err = scraper.GetPage("ops/draft")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(page.Sections) != 3 || page.Sections[0].Content != "Draft intro.\n" || page.Sections[2].Content != "Revert.\n" {
+		t.Errorf("Unexpected sections: %+v", page.Sections)
+	}
+
+	// Test 3: Missing page
+	_, err = scraper.GetPage("ops/missing")
+	if e, ok := err.(*scrape.WikiJSError); !ok || e.Code != "wikijs6003" {
+		t.Errorf("Expected a WikiJSError, got %v", err)
+	}
+
+	// Test 4: Missing token
+	scraper.Token = ""
+	_, err = scraper.GetPage("ops/deployment")
+	if e, ok := err.(*scrape.WikiJSError); !ok || e.Code != "http403" {
+		t.Errorf("Expected a WikiJSError, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package scrape
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	wikitextHeading     = regexp.MustCompile(`^==([^=].*?)==\s*$`)
+	wikitextComment     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	wikitextRef         = regexp.MustCompile(`(?is)<ref[^>/]*/>|<ref[^>]*>.*?</ref>`)
+	wikitextExternal    = regexp.MustCompile(`\[(?:https?:)?//[^\s\]]+(?:\s+([^\]]*))?\]`)
+	wikitextEmphasis    = regexp.MustCompile(`'{2,}`)
+	wikitextTag         = regexp.MustCompile(`<[^>]+>`)
+	wikitextMediaPrefix = regexp.MustCompile(`(?i)^\s*:?\s*(file|image|category|media):`)
+)
+
+// ParseWikitextSections splits raw wikitext into Sections in the same shape
+// as the HTML section parsers: an "Introduction" section with index 0,
+// followed by one section per level 2 heading. Subsection content is folded
+// into its parent section. Only paragraph text is kept; templates, tables,
+// references, lists and media are discarded and links are reduced to their
+// display text.
+func ParseWikitextSections(text string) []*Section {
+	sections := []*Section{{Heading: "Introduction", Index: 0}}
+	var body strings.Builder
+	flush := func() {
+		sections[len(sections)-1].Content = wikitextToText(body.String())
+		body.Reset()
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if m := wikitextHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			sections = append(sections, &Section{
+				Heading: strings.TrimSpace(wikitextToText(m[1])),
+				Index:   len(sections),
+			})
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+	return sections
+}
+
+// wikitextToText reduces a fragment of wikitext to its paragraph text.
+// Each paragraph is terminated by a newline.
+func wikitextToText(text string) string {
+	text = wikitextComment.ReplaceAllString(text, "")
+	text = wikitextRef.ReplaceAllString(text, "")
+	text = removeNested(text, "{{", "}}")
+	text = removeNested(text, "{|", "|}")
+	text = replaceLinks(text)
+	text = wikitextExternal.ReplaceAllString(text, "$1")
+	text = wikitextEmphasis.ReplaceAllString(text, "")
+	text = wikitextTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	var out strings.Builder
+	var paragraph []string
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString(strings.Join(paragraph, " "))
+			out.WriteByte('\n')
+			paragraph = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "=") || strings.HasPrefix(line, "__") || strings.ContainsAny(line[:1], "*#:;|!") {
+			endParagraph()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	endParagraph()
+	return out.String()
+}
+
+// removeNested removes every (possibly nested) span of text enclosed by
+// the open and close delimiters. Unterminated spans run to the end of the text.
+func removeNested(text string, open string, close string) string {
+	var out strings.Builder
+	depth := 0
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], open):
+			depth++
+			i += len(open)
+		case depth > 0 && strings.HasPrefix(text[i:], close):
+			depth--
+			i += len(close)
+		default:
+			if depth == 0 {
+				out.WriteByte(text[i])
+			}
+			i++
+		}
+	}
+	return out.String()
+}
+
+// replaceLinks replaces internal [[links]] with their display text. Links to
+// files, images, media and categories are removed along with their captions.
+func replaceLinks(text string) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(text, "[[")
+		if start < 0 {
+			out.WriteString(text)
+			return out.String()
+		}
+		out.WriteString(text[:start])
+		end := matchingLinkEnd(text, start)
+		if end < 0 {
+			out.WriteString(text[start:])
+			return out.String()
+		}
+		inner := text[start+2 : end]
+		text = text[end+2:]
+		if wikitextMediaPrefix.MatchString(inner) {
+			continue
+		}
+		if pipe := strings.Index(inner, "|"); pipe >= 0 {
+			inner = inner[pipe+1:]
+		}
+		out.WriteString(replaceLinks(inner))
+	}
+}
+
+// matchingLinkEnd returns the index of the "]]" closing the link opened at
+// start, accounting for nested links, or -1 if it is never closed.
+func matchingLinkEnd(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text)-1; i++ {
+		switch text[i : i+2] {
+		case "[[":
+			depth++
+			i++
+		case "]]":
+			depth--
+			if depth == 0 {
+				return i
+			}
+			i++
+		}
+	}
+	return -1
+}
@@ -0,0 +1,44 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testWikitext = `{{Infobox quest
+|name = Dragon Slayer I
+|members = Yes
+}}
+'''Dragon Slayer I''' is a [[quest]] in which the player slays [[Elvarg|the dragon]].<ref>{{cite web|url=x}}</ref>
+It was released in 2001.<!-- hidden -->
+
+[[File:Elvarg.png|thumb|[[Elvarg]] on [[Crandor]]]]
+== Walkthrough ==
+Speak to the [[Guildmaster]] in the [https://example.com Champions' Guild].
+* Bring a [[anti-dragon shield]]
+=== Map pieces ===
+There are &amp; three pieces.
+{| class="wikitable"
+| Piece || Location
+|}
+==[[Rewards|Rewards]]==
+[[Category:Quests]]
+`
+
+func TestParseWikitextSections(t *testing.T) {
+	sections := scrape.ParseWikitextSections(testWikitext)
+	want := []scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "Dragon Slayer I is a quest in which the player slays the dragon. It was released in 2001.\n"},
+		{Heading: "Walkthrough", Index: 1, Content: "Speak to the Guildmaster in the Champions' Guild.\nThere are & three pieces.\n"},
+		{Heading: "Rewards", Index: 2, Content: ""},
+	}
+	if len(sections) != len(want) {
+		t.Fatalf("Section count mismatch. Got: %d, Want: %d", len(sections), len(want))
+	}
+	for i, s := range sections {
+		if *s != want[i] {
+			t.Errorf("Section mismatch at index %d.\nGot:  %+v\nWant: %+v", i, *s, want[i])
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package scrape
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+// Number of manifest pages looked up per pass over an XML dump.
+const xmlDumpBatchSize = 1000
+
+// Wraps methods for retrieving and parsing pages from a MediaWiki
+// XML dump, as produced by Special:Export or published on
+// dumps.wikimedia.org. Path may point to a plain, gzip (.gz) or
+// bzip2 (.bz2) compressed file. The dump is streamed on every
+// request rather than loaded into memory.
+type XMLDumpScraper struct {
+	Path string
+}
+
+// Representation of a single <page> element of an XML dump. Dumps
+// containing full page history hold several revisions, the last
+// of which is the most recent.
+type xmlDumpPage struct {
+	Title    string `xml:"title"`
+	Redirect *struct {
+		Title string `xml:"title,attr"`
+	} `xml:"redirect"`
+	Revisions []struct {
+		Text string `xml:"text"`
+	} `xml:"revision"`
+}
+
+// XMLDumpError indicates that a page could not be found in, or read
+// from, an XML dump.
+type XMLDumpError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted XMLDumpError including code and
+// additional information.
+func (e *XMLDumpError) Error() string {
+	return fmt.Sprintf("XMLDumpError: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// open opens the dump, transparently decompressing it based on
+// its file extension, in any case.
+func (s *XMLDumpScraper) open() (io.Reader, io.Closer, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch path := strings.ToLower(s.Path); {
+	case strings.HasSuffix(path, ".bz2"):
+		return bzip2.NewReader(file), file, nil
+	case strings.HasSuffix(path, ".gz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return gz, file, nil
+	}
+	return file, file, nil
+}
+
+// scan streams through every <page> element of the dump, calling fn
+// for each one until fn returns false or the dump ends.
+func (s *XMLDumpScraper) scan(fn func(*xmlDumpPage) bool) error {
+	r, closer, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "page" {
+			continue
+		}
+		var page xmlDumpPage
+		if err := decoder.DecodeElement(&page, &start); err != nil {
+			return err
+		}
+		if !fn(&page) {
+			return nil
+		}
+	}
+}
+
+// findPages looks up every title in a single pass over the dump, then
+// in further passes for any redirect targets. Returns the text of each
+// page found keyed by canonical title, and the canonical title each
+// requested title resolved to.
+func (s *XMLDumpScraper) findPages(titles []string) (map[string]string, map[string]string, error) {
+	resolved := map[string]string{}
+	wanted := map[string]bool{}
+	for _, title := range titles {
+		resolved[title] = util.NormalizeTitle(title)
+		wanted[resolved[title]] = true
+	}
+	content := map[string]string{}
+	redirects := map[string]string{}
+	// Follow at most one level of redirects, as MediaWiki does.
+	for pass := 0; pass < 2 && len(wanted) > 0; pass++ {
+		err := s.scan(func(page *xmlDumpPage) bool {
+			if !wanted[page.Title] {
+				return true
+			}
+			delete(wanted, page.Title)
+			if page.Redirect != nil && pass == 0 {
+				target := util.NormalizeTitle(page.Redirect.Title)
+				redirects[page.Title] = target
+				if _, found := content[target]; !found {
+					wanted[target] = true
+				}
+			} else if len(page.Revisions) > 0 {
+				content[page.Title] = page.Revisions[len(page.Revisions)-1].Text
+			}
+			return len(wanted) > 0
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for title, canonical := range resolved {
+		if target, ok := redirects[canonical]; ok {
+			resolved[title] = target
+		}
+	}
+	return content, resolved, nil
+}
+
+// GetPage streams through the dump until it finds the page with the title
+// specified by path, following a redirect if necessary, and parses its
+// wikitext with ParseWikitextSections.
+//
+// Can error when:
+//   - The dump cannot be read
+//   - The page is not in the dump
+func (s *XMLDumpScraper) GetPage(path string) (*Page, error) {
+	pages, _, err := s.GetPages([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, &XMLDumpError{
+			Code: "missingtitle",
+			Info: fmt.Sprintf("The page %s was not found in %s", path, s.Path),
+		}
+	}
+	return pages[0], nil
+}
+
+// GetSections keeps the sections of the page specified by path selected by
+// the filter, after reading the whole page text from the dump.
+func (s *XMLDumpScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
+
+// BatchSize returns the maximum number of pages GetPages accepts.
+func (s *XMLDumpScraper) BatchSize() int {
+	return xmlDumpBatchSize
+}
+
+// GetPages looks up several pages in as few passes over the dump as
+// possible. Pages are returned in the order requested, along with the
+// titles which are not in the dump.
+func (s *XMLDumpScraper) GetPages(paths []string) ([]*Page, []string, error) {
+	content, resolved, err := s.findPages(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pages []*Page
+	var missing []string
+	seen := map[string]bool{}
+	for _, path := range paths {
+		title := resolved[path]
+		text, ok := content[title]
+		if !ok {
+			missing = append(missing, path)
+			continue
+		}
+		if seen[title] {
+			continue
+		}
+		seen[title] = true
+		pages = append(pages, &Page{
+			Title:    title,
+			Sections: ParseWikitextSections(text),
+		})
+	}
+	return pages, missing, nil
+}
@@ -0,0 +1,94 @@
+package scrape_test
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+)
+
+const testDump = `<mediawiki xmlns="http://www.mediawiki.org/xml/export-0.10/" version="0.10">
+  <siteinfo><sitename>Test Wiki</sitename></siteinfo>
+  <page>
+    <title>Dragon</title>
+    <ns>0</ns>
+    <redirect title="Dragons" />
+    <revision><text>#REDIRECT [[Dragons]]</text></revision>
+  </page>
+  <page>
+    <title>Bear</title>
+    <ns>0</ns>
+    <revision><text>Old text.</text></revision>
+    <revision><text>Bears are [[mammal]]s.
+== Diet ==
+Bears eat.</text></revision>
+  </page>
+  <page>
+    <title>Dragons</title>
+    <ns>0</ns>
+    <revision><text>Dragons are big.</text></revision>
+  </page>
+</mediawiki>`
+
+// writeTestDumps writes the test dump both uncompressed and gzip compressed,
+// the latter with an uppercase extension.
+func writeTestDumps(t *testing.T) []string {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "dump.xml")
+	if err := os.WriteFile(plain, []byte(testDump), 0644); err != nil {
+		t.Fatalf("Failed to write dump: %v", err)
+	}
+	compressed := filepath.Join(dir, "dump.XML.GZ")
+	file, err := os.Create(compressed)
+	if err != nil {
+		t.Fatalf("Failed to create dump: %v", err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte(testDump))
+	gz.Close()
+	file.Close()
+	return []string{plain, compressed}
+}
+
+func TestXMLDumpScraper(t *testing.T) {
+	for _, path := range writeTestDumps(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			scraper := &scrape.XMLDumpScraper{Path: path}
+
+			// Test 1: Latest revision, with underscores and lowercase first letter
+			page, err := scraper.GetPage("bear")
+			if err != nil {
+				t.Fatalf("Failed to get page: %v", err)
+			}
+			if page.Title != "Bear" || len(page.Sections) != 2 || page.Sections[0].Content != "Bears are mammals.\n" {
+				t.Errorf("Unexpected page: %+v", page)
+			}
+
+			// Test 2: Batch with redirect and missing page
+			pages, missing, err := scraper.GetPages([]string{"Dragon", "Cheesebiscuit", "Bear", "bear"})
+			if err != nil {
+				t.Fatalf("Failed to get pages: %v", err)
+			}
+			if len(pages) != 2 || pages[0].Title != "Dragons" || pages[1].Title != "Bear" {
+				t.Errorf("Unexpected pages: %v", pages)
+			}
+			if len(missing) != 1 || missing[0] != "Cheesebiscuit" {
+				t.Errorf("Unexpected missing pages: %v", missing)
+			}
+
+			// Test 3: Missing page
+			_, err = scraper.GetPage("Cheesebiscuit")
+			if _, ok := err.(*scrape.XMLDumpError); !ok {
+				t.Errorf("Expected an XMLDumpError, got %v", err)
+			}
+
+			// Test 4: Blank title
+			_, err = scraper.GetPage(" _ ")
+			if _, ok := err.(*scrape.XMLDumpError); !ok {
+				t.Errorf("Expected an XMLDumpError, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,421 @@
+// Package zim reads Kiwix ZIM archives, the offline format used to
+// distribute full snapshots of Wikipedia and other wikis. Articles are
+// looked up by title or url through the archive's directory entries and
+// their content is read from the (optionally compressed) cluster holding it.
+//
+// Format reference: https://wiki.openzim.org/wiki/ZIM_file_format
+package zim
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Magic number at the start of every ZIM archive.
+const magicNumber = 72173914
+
+// Special mime type indices of directory entries.
+const (
+	mimeRedirect   = 0xffff
+	mimeLinkTarget = 0xfffe
+	mimeDeleted    = 0xfffd
+)
+
+// Cluster compression types, stored in the low 4 bits of the cluster info byte.
+const (
+	compressionDefault = 0
+	compressionNone    = 1
+	compressionZlib    = 2
+	compressionBzip2   = 3
+	compressionXZ      = 4
+	compressionZstd    = 5
+)
+
+// Maximum number of redirects followed when reading an entry's content.
+const maxRedirects = 8
+
+// header is the fixed size header at the start of a ZIM archive.
+type header struct {
+	MagicNumber   uint32
+	MajorVersion  uint16
+	MinorVersion  uint16
+	UUID          [16]byte
+	EntryCount    uint32
+	ClusterCount  uint32
+	URLPtrPos     uint64
+	TitlePtrPos   uint64
+	ClusterPtrPos uint64
+	MimeListPos   uint64
+	MainPage      uint32
+	LayoutPage    uint32
+	ChecksumPos   uint64
+}
+
+// Archive is an open ZIM file.
+type Archive struct {
+	file      *os.File
+	header    header
+	mimeTypes []string
+
+	// The most recently decompressed cluster, as articles are often
+	// read in runs from the same cluster.
+	clusterIndex uint32
+	cluster      []byte
+	blobOffsets  []uint64
+}
+
+// Entry is a directory entry of an archive. Content entries locate their
+// data by Cluster and Blob, redirect entries point to another entry by
+// RedirectIndex.
+type Entry struct {
+	Namespace     byte
+	URL           string
+	Title         string
+	MimeType      string
+	Redirect      bool
+	RedirectIndex uint32
+	Cluster       uint32
+	Blob          uint32
+}
+
+// NotFoundError indicates that no entry in the archive matched a lookup.
+type NotFoundError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted NotFoundError including code and
+// additional information.
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("ZIMNotFoundError: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// Open opens the ZIM archive at path and reads its header and mime type list.
+//
+// Can error when:
+//   - The file cannot be read
+//   - The file is not a ZIM archive
+func Open(path string) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{file: file}
+	if err := a.readHeader(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// Close closes the underlying file.
+func (a *Archive) Close() error {
+	return a.file.Close()
+}
+
+// readHeader reads the archive header and mime type list.
+func (a *Archive) readHeader() error {
+	r := io.NewSectionReader(a.file, 0, 80)
+	if err := binary.Read(r, binary.LittleEndian, &a.header); err != nil {
+		return err
+	}
+	if a.header.MagicNumber != magicNumber {
+		return fmt.Errorf("not a ZIM archive")
+	}
+	mimes := bufio.NewReader(io.NewSectionReader(a.file, int64(a.header.MimeListPos), 1<<20))
+	for {
+		mime, err := readString(mimes)
+		if err != nil {
+			return err
+		}
+		if mime == "" {
+			return nil
+		}
+		a.mimeTypes = append(a.mimeTypes, mime)
+	}
+}
+
+// EntryCount returns the number of directory entries in the archive.
+func (a *Archive) EntryCount() int {
+	return int(a.header.EntryCount)
+}
+
+// readString reads a null terminated string.
+func readString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(s, "\x00"), nil
+}
+
+// readUint reads a little endian unsigned integer of the given size in bytes.
+func (a *Archive) readUint(offset uint64, size int) (uint64, error) {
+	buf := make([]byte, size)
+	if _, err := a.file.ReadAt(buf, int64(offset)); err != nil {
+		return 0, err
+	}
+	if size == 4 {
+		return uint64(binary.LittleEndian.Uint32(buf)), nil
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+// EntryAt returns the directory entry at index in the url ordered pointer list.
+func (a *Archive) EntryAt(index uint32) (*Entry, error) {
+	if index >= a.header.EntryCount {
+		return nil, fmt.Errorf("entry index %d out of range", index)
+	}
+	offset, err := a.readUint(a.header.URLPtrPos+8*uint64(index), 8)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(io.NewSectionReader(a.file, int64(offset), 1<<16))
+	var fixed struct {
+		MimeType  uint16
+		ParamLen  uint8
+		Namespace byte
+		Revision  uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &fixed); err != nil {
+		return nil, err
+	}
+	entry := &Entry{Namespace: fixed.Namespace}
+	switch fixed.MimeType {
+	case mimeRedirect:
+		entry.Redirect = true
+		if err := binary.Read(r, binary.LittleEndian, &entry.RedirectIndex); err != nil {
+			return nil, err
+		}
+	case mimeLinkTarget, mimeDeleted:
+		// Link targets and deleted entries have no cluster or redirect
+		// field, their url follows the common header.
+	default:
+		if err := binary.Read(r, binary.LittleEndian, &entry.Cluster); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &entry.Blob); err != nil {
+			return nil, err
+		}
+		if int(fixed.MimeType) < len(a.mimeTypes) {
+			entry.MimeType = a.mimeTypes[fixed.MimeType]
+		}
+	}
+	if entry.URL, err = readString(r); err != nil {
+		return nil, err
+	}
+	if entry.Title, err = readString(r); err != nil {
+		return nil, err
+	}
+	if entry.Title == "" {
+		entry.Title = entry.URL
+	}
+	return entry, nil
+}
+
+// entryAtTitleIndex returns the directory entry at index in the title
+// ordered pointer list.
+func (a *Archive) entryAtTitleIndex(index int) (*Entry, error) {
+	urlIndex, err := a.readUint(a.header.TitlePtrPos+4*uint64(index), 4)
+	if err != nil {
+		return nil, err
+	}
+	return a.EntryAt(uint32(urlIndex))
+}
+
+// search binary searches one of the pointer lists, both of which are
+// sorted by namespace and then by key.
+func (a *Archive) search(namespace byte, key string, at func(int) (*Entry, error), keyOf func(*Entry) string) (*Entry, error) {
+	var searchErr error
+	n := int(a.header.EntryCount)
+	i := sort.Search(n, func(i int) bool {
+		entry, err := at(i)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		if entry.Namespace != namespace {
+			return entry.Namespace > namespace
+		}
+		return keyOf(entry) >= key
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+	if i < n {
+		entry, err := at(i)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Namespace == namespace && keyOf(entry) == key {
+			return entry, nil
+		}
+	}
+	return nil, &NotFoundError{
+		Code: "entrynotfound",
+		Info: fmt.Sprintf("No entry %s/%s in the archive", string(namespace), key),
+	}
+}
+
+// EntryByTitle looks up an entry by namespace and exact title.
+func (a *Archive) EntryByTitle(namespace byte, title string) (*Entry, error) {
+	return a.search(namespace, title, a.entryAtTitleIndex, func(e *Entry) string { return e.Title })
+}
+
+// EntryByURL looks up an entry by namespace and exact url.
+func (a *Archive) EntryByURL(namespace byte, url string) (*Entry, error) {
+	at := func(i int) (*Entry, error) { return a.EntryAt(uint32(i)) }
+	return a.search(namespace, url, at, func(e *Entry) string { return e.URL })
+}
+
+// MainPage returns the archive's main page entry.
+func (a *Archive) MainPage() (*Entry, error) {
+	return a.EntryAt(a.header.MainPage)
+}
+
+// Resolve follows redirects from entry to the content entry they point to.
+func (a *Archive) Resolve(entry *Entry) (*Entry, error) {
+	for i := 0; entry.Redirect; i++ {
+		if i == maxRedirects {
+			return nil, fmt.Errorf("too many redirects from %s", entry.URL)
+		}
+		var err error
+		entry, err = a.EntryAt(entry.RedirectIndex)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// Content returns the data of an entry, following redirects.
+func (a *Archive) Content(entry *Entry) ([]byte, error) {
+	entry, err := a.Resolve(entry)
+	if err != nil {
+		return nil, err
+	}
+	if entry.MimeType == "" {
+		// Link targets and deleted entries have no data.
+		return nil, fmt.Errorf("entry %s has no content", entry.URL)
+	}
+	if err := a.loadCluster(entry.Cluster); err != nil {
+		return nil, err
+	}
+	if int(entry.Blob)+1 >= len(a.blobOffsets) {
+		return nil, fmt.Errorf("blob %d out of range in cluster %d", entry.Blob, entry.Cluster)
+	}
+	start, end := a.blobOffsets[entry.Blob], a.blobOffsets[entry.Blob+1]
+	if start > end || end > uint64(len(a.cluster)) {
+		return nil, fmt.Errorf("corrupt blob %d in cluster %d", entry.Blob, entry.Cluster)
+	}
+	return a.cluster[start:end], nil
+}
+
+// loadCluster decompresses a cluster and reads its blob offsets, unless
+// it is already loaded.
+func (a *Archive) loadCluster(index uint32) error {
+	if a.cluster != nil && a.clusterIndex == index {
+		return nil
+	}
+	if index >= a.header.ClusterCount {
+		return fmt.Errorf("cluster %d out of range", index)
+	}
+	start, err := a.readUint(a.header.ClusterPtrPos+8*uint64(index), 8)
+	if err != nil {
+		return err
+	}
+	end := a.header.ChecksumPos
+	if index+1 < a.header.ClusterCount {
+		if end, err = a.readUint(a.header.ClusterPtrPos+8*uint64(index+1), 8); err != nil {
+			return err
+		}
+	}
+	if end <= start {
+		return fmt.Errorf("corrupt cluster %d", index)
+	}
+	info := make([]byte, 1)
+	if _, err := a.file.ReadAt(info, int64(start)); err != nil {
+		return err
+	}
+	data, err := decompress(info[0]&0x0f, io.NewSectionReader(a.file, int64(start)+1, int64(end-start-1)))
+	if err != nil {
+		return fmt.Errorf("cluster %d: %w", index, err)
+	}
+	offsets, err := blobOffsets(data, info[0]&0x10 != 0)
+	if err != nil {
+		return fmt.Errorf("cluster %d: %w", index, err)
+	}
+	a.clusterIndex, a.cluster, a.blobOffsets = index, data, offsets
+	return nil
+}
+
+// decompress reads the raw cluster data, decompressing it according
+// to its compression type.
+func decompress(compression byte, r io.Reader) ([]byte, error) {
+	switch compression {
+	case compressionDefault, compressionNone:
+		return io.ReadAll(r)
+	case compressionZlib:
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	case compressionBzip2:
+		return io.ReadAll(bzip2.NewReader(r))
+	case compressionXZ:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(xr)
+	case compressionZstd:
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		return io.ReadAll(decoder)
+	}
+	return nil, fmt.Errorf("unsupported compression type %d", compression)
+}
+
+// blobOffsets reads the offset list at the start of decompressed cluster
+// data. The first offset also gives the size of the list itself.
+func blobOffsets(data []byte, extended bool) ([]uint64, error) {
+	size := 4
+	if extended {
+		size = 8
+	}
+	read := func(i int) uint64 {
+		b := data[i*size : (i+1)*size]
+		if extended {
+			return binary.LittleEndian.Uint64(b)
+		}
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+	if len(data) < size {
+		return nil, fmt.Errorf("truncated cluster")
+	}
+	first := read(0)
+	count := int(first) / size
+	if first%uint64(size) != 0 || count*size > len(data) {
+		return nil, fmt.Errorf("corrupt blob offsets")
+	}
+	offsets := make([]uint64, count)
+	for i := range offsets {
+		offsets[i] = read(i)
+	}
+	return offsets, nil
+}
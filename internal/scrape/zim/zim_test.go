@@ -0,0 +1,187 @@
+package zim_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mal0ner/wikiscrape/internal/scrape/zim"
+)
+
+// testEntry describes a directory entry of a test archive. Entries must
+// be given in url order, which is also their title order here.
+type testEntry struct {
+	url      string
+	redirect uint32
+	cluster  uint32
+	isLink   bool
+	deleted  bool
+}
+
+// cluster builds the data of a cluster holding the given blobs, compressed
+// with zstd when compress is set.
+func cluster(t *testing.T, compress bool, blobs ...string) []byte {
+	var data bytes.Buffer
+	offset := uint32(4 * (len(blobs) + 1))
+	for _, blob := range blobs {
+		binary.Write(&data, binary.LittleEndian, offset)
+		offset += uint32(len(blob))
+	}
+	binary.Write(&data, binary.LittleEndian, offset)
+	for _, blob := range blobs {
+		data.WriteString(blob)
+	}
+	if !compress {
+		return append([]byte{1}, data.Bytes()...)
+	}
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("Failed to create zstd encoder: %v", err)
+	}
+	defer encoder.Close()
+	return append([]byte{5}, encoder.EncodeAll(data.Bytes(), nil)...)
+}
+
+// writeTestArchive writes a ZIM archive with the given entries and clusters.
+// Content entries always refer to blob 0 of their cluster.
+func writeTestArchive(t *testing.T, entries []testEntry, clusters [][]byte) string {
+	mimes := []byte("text/html\x00\x00")
+	var dirents [][]byte
+	for _, e := range entries {
+		var d bytes.Buffer
+		if e.deleted {
+			// Deleted entries have no cluster or redirect field.
+			binary.Write(&d, binary.LittleEndian, uint16(0xfffd))
+			d.Write([]byte{0, 'C', 0, 0, 0, 0})
+		} else if e.isLink {
+			binary.Write(&d, binary.LittleEndian, uint16(0xffff))
+			d.Write([]byte{0, 'C', 0, 0, 0, 0})
+			binary.Write(&d, binary.LittleEndian, e.redirect)
+		} else {
+			binary.Write(&d, binary.LittleEndian, uint16(0))
+			d.Write([]byte{0, 'C', 0, 0, 0, 0})
+			binary.Write(&d, binary.LittleEndian, e.cluster)
+			binary.Write(&d, binary.LittleEndian, uint32(0))
+		}
+		d.WriteString(e.url + "\x00\x00")
+		dirents = append(dirents, d.Bytes())
+	}
+	n, c := uint64(len(entries)), uint64(len(clusters))
+	urlPtrPos := 80 + uint64(len(mimes))
+	titlePtrPos := urlPtrPos + 8*n
+	clusterPtrPos := titlePtrPos + 4*n
+	pos := clusterPtrPos + 8*c
+
+	var body bytes.Buffer
+	var urlPtrs, titlePtrs, clusterPtrs bytes.Buffer
+	for i, d := range dirents {
+		binary.Write(&urlPtrs, binary.LittleEndian, pos+uint64(body.Len()))
+		binary.Write(&titlePtrs, binary.LittleEndian, uint32(i))
+		body.Write(d)
+	}
+	for _, cl := range clusters {
+		binary.Write(&clusterPtrs, binary.LittleEndian, pos+uint64(body.Len()))
+		body.Write(cl)
+	}
+	checksumPos := pos + uint64(body.Len())
+
+	var file bytes.Buffer
+	binary.Write(&file, binary.LittleEndian, uint32(72173914))
+	binary.Write(&file, binary.LittleEndian, [2]uint16{6, 1})
+	file.Write(make([]byte, 16))
+	binary.Write(&file, binary.LittleEndian, [2]uint32{uint32(n), uint32(c)})
+	binary.Write(&file, binary.LittleEndian, [4]uint64{urlPtrPos, titlePtrPos, clusterPtrPos, 80})
+	binary.Write(&file, binary.LittleEndian, [2]uint32{0, 0xffffffff})
+	binary.Write(&file, binary.LittleEndian, checksumPos)
+	file.Write(mimes)
+	file.Write(urlPtrs.Bytes())
+	file.Write(titlePtrs.Bytes())
+	file.Write(clusterPtrs.Bytes())
+	file.Write(body.Bytes())
+	file.Write(make([]byte, 16))
+
+	path := filepath.Join(t.TempDir(), "test.zim")
+	if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return path
+}
+
+func TestArchive(t *testing.T) {
+	path := writeTestArchive(t,
+		[]testEntry{
+			{url: "Bear", cluster: 0},
+			{url: "Cub", deleted: true},
+			{url: "Dragon", isLink: true, redirect: 3},
+			{url: "Dragons", cluster: 1},
+		},
+		[][]byte{
+			cluster(t, false, "<p>Bears.</p>"),
+			cluster(t, true, "<p>Dragons.</p>"),
+		},
+	)
+	archive, err := zim.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer archive.Close()
+
+	cases := []struct {
+		Title string
+		Want  string
+	}{
+		{"Bear", "<p>Bears.</p>"},
+		{"Dragon", "<p>Dragons.</p>"},
+		{"Dragons", "<p>Dragons.</p>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Title, func(t *testing.T) {
+			entry, err := archive.EntryByTitle('C', tc.Title)
+			if err != nil {
+				t.Fatalf("Failed to find entry: %v", err)
+			}
+			content, err := archive.Content(entry)
+			if err != nil {
+				t.Fatalf("Failed to read content: %v", err)
+			}
+			if string(content) != tc.Want {
+				t.Errorf("Content mismatch. Got: %s, Want: %s", content, tc.Want)
+			}
+		})
+	}
+
+	// Deleted entries are read without a cluster field and have no content
+	entry, err := archive.EntryByURL('C', "Cub")
+	if err != nil {
+		t.Fatalf("Failed to find deleted entry: %v", err)
+	}
+	if entry.Title != "Cub" {
+		t.Errorf("Title mismatch. Got: %q, Want: Cub", entry.Title)
+	}
+	if _, err := archive.Content(entry); err == nil {
+		t.Error("Expected an error reading a deleted entry, but got nil")
+	}
+
+	// Missing entries
+	_, err = archive.EntryByURL('C', "Cheesebiscuit")
+	if _, ok := err.(*zim.NotFoundError); !ok {
+		t.Errorf("Expected a NotFoundError, got %v", err)
+	}
+	_, err = archive.EntryByTitle('A', "Bear")
+	if _, ok := err.(*zim.NotFoundError); !ok {
+		t.Errorf("Expected a NotFoundError for the wrong namespace, got %v", err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.zim")
+	if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := zim.Open(path); err == nil {
+		t.Error("Expected an error for a file that is not a ZIM archive, but got nil")
+	}
+}
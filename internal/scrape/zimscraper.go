@@ -0,0 +1,84 @@
+package scrape
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape/zim"
+)
+
+// Namespaces holding articles in ZIM archives. Archives created since
+// version 6.1 of the format use "C", older archives use "A".
+var zimArticleNamespaces = []byte{'C', 'A'}
+
+// Wraps methods for retrieving and parsing articles from a local Kiwix
+// ZIM archive, such as wikipedia_en_all_nopic.zim. Articles are stored
+// as rendered MediaWiki HTML and parsed with the same section parser
+// as pages fetched from the MediaWiki API.
+type ZIMScraper struct {
+	Path string
+}
+
+// findArticle looks up an article by title, then by url, in each of the
+// article namespaces. Page names taken from urls use underscores in
+// place of spaces, so both forms are tried. Deleted entries and link
+// targets, which have no content, are not articles.
+func findArticle(archive *zim.Archive, path string) (*zim.Entry, error) {
+	title := strings.ReplaceAll(path, "_", " ")
+	url := strings.ReplaceAll(path, " ", "_")
+	isArticle := func(entry *zim.Entry) bool {
+		return entry.Redirect || entry.MimeType != ""
+	}
+	for _, ns := range zimArticleNamespaces {
+		if entry, err := archive.EntryByTitle(ns, title); err == nil && isArticle(entry) {
+			return entry, nil
+		}
+		if entry, err := archive.EntryByURL(ns, url); err == nil && isArticle(entry) {
+			return entry, nil
+		}
+	}
+	return nil, &zim.NotFoundError{
+		Code: "missingtitle",
+		Info: fmt.Sprintf("The article %s was not found in the archive", path),
+	}
+}
+
+// GetPage looks up the article specified by path in the archive,
+// following redirects, and parses its HTML into sections.
+//
+// Can error when:
+//   - The archive cannot be read
+//   - The article is not in the archive
+func (s *ZIMScraper) GetPage(path string) (*Page, error) {
+	archive, err := zim.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	entry, err := findArticle(archive, path)
+	if err != nil {
+		return nil, err
+	}
+	entry, err = archive.Resolve(entry)
+	if err != nil {
+		return nil, err
+	}
+	content, err := archive.Content(entry)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := parseMediaWikiHTML(string(content), "")
+	if err != nil {
+		return nil, err
+	}
+	return &Page{
+		Title:    entry.Title,
+		Sections: sections,
+	}, nil
+}
+
+// GetSections keeps the sections of the article specified by path selected
+// by the filter, after reading the whole article from the archive.
+func (s *ZIMScraper) GetSections(path string, filter *SectionFilter) (*Page, error) {
+	return getFilteredPage(s, path, filter)
+}
@@ -0,0 +1,129 @@
+package scrape_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/scrape/zim"
+)
+
+// testZIMEntry describes a directory entry of a test archive: an article
+// with its HTML, a redirect to the entry at index redirect, or a deleted
+// entry. Entries must be given in url order.
+type testZIMEntry struct {
+	url      string
+	html     string
+	redirect uint32
+	isLink   bool
+	deleted  bool
+}
+
+// writeTestZIM writes an uncompressed ZIM archive holding the entries in
+// the "C" namespace, each article in a cluster of its own.
+func writeTestZIM(t *testing.T, entries []testZIMEntry) string {
+	mimes := []byte("text/html\x00\x00")
+	var dirents, clusters [][]byte
+	for _, e := range entries {
+		var d bytes.Buffer
+		switch {
+		case e.deleted:
+			binary.Write(&d, binary.LittleEndian, uint16(0xfffd))
+			d.Write([]byte{0, 'C', 0, 0, 0, 0})
+		case e.isLink:
+			binary.Write(&d, binary.LittleEndian, uint16(0xffff))
+			d.Write([]byte{0, 'C', 0, 0, 0, 0})
+			binary.Write(&d, binary.LittleEndian, e.redirect)
+		default:
+			binary.Write(&d, binary.LittleEndian, uint16(0))
+			d.Write([]byte{0, 'C', 0, 0, 0, 0})
+			binary.Write(&d, binary.LittleEndian, uint32(len(clusters)))
+			binary.Write(&d, binary.LittleEndian, uint32(0))
+			var cluster bytes.Buffer
+			cluster.WriteByte(1)
+			binary.Write(&cluster, binary.LittleEndian, [2]uint32{8, uint32(8 + len(e.html))})
+			cluster.WriteString(e.html)
+			clusters = append(clusters, cluster.Bytes())
+		}
+		d.WriteString(e.url + "\x00\x00")
+		dirents = append(dirents, d.Bytes())
+	}
+	n, c := uint64(len(entries)), uint64(len(clusters))
+	urlPtrPos := 80 + uint64(len(mimes))
+	titlePtrPos := urlPtrPos + 8*n
+	clusterPtrPos := titlePtrPos + 4*n
+	pos := clusterPtrPos + 8*c
+
+	var body, urlPtrs, titlePtrs, clusterPtrs bytes.Buffer
+	for i, d := range dirents {
+		binary.Write(&urlPtrs, binary.LittleEndian, pos+uint64(body.Len()))
+		binary.Write(&titlePtrs, binary.LittleEndian, uint32(i))
+		body.Write(d)
+	}
+	for _, cl := range clusters {
+		binary.Write(&clusterPtrs, binary.LittleEndian, pos+uint64(body.Len()))
+		body.Write(cl)
+	}
+
+	var file bytes.Buffer
+	binary.Write(&file, binary.LittleEndian, uint32(72173914))
+	binary.Write(&file, binary.LittleEndian, [2]uint16{6, 1})
+	file.Write(make([]byte, 16))
+	binary.Write(&file, binary.LittleEndian, [2]uint32{uint32(n), uint32(c)})
+	binary.Write(&file, binary.LittleEndian, [4]uint64{urlPtrPos, titlePtrPos, clusterPtrPos, 80})
+	binary.Write(&file, binary.LittleEndian, [2]uint32{0, 0xffffffff})
+	binary.Write(&file, binary.LittleEndian, pos+uint64(body.Len()))
+	file.Write(mimes)
+	file.Write(urlPtrs.Bytes())
+	file.Write(titlePtrs.Bytes())
+	file.Write(clusterPtrs.Bytes())
+	file.Write(body.Bytes())
+	file.Write(make([]byte, 16))
+
+	path := filepath.Join(t.TempDir(), "test.zim")
+	if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return path
+}
+
+func TestZIMScraper(t *testing.T) {
+	path := writeTestZIM(t, []testZIMEntry{
+		{url: "Bear", html: testPageHTML},
+		{url: "Bears", isLink: true, redirect: 0},
+		{url: "Cub", deleted: true},
+		{url: "Polar_bear", html: `<div class="mw-parser-output"><p>White bears.</p></div>`},
+	})
+	scraper := &scrape.ZIMScraper{Path: path}
+
+	// Test 1: Article, and an article reached through a redirect
+	for _, name := range []string{"Bear", "Bears"} {
+		page, err := scraper.GetPage(name)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", name, err)
+		}
+		if page.Title != "Bear" || len(page.Sections) != 3 || page.Sections[2].Content != "Bears sleep.Bears eat." {
+			t.Errorf("Unexpected page for %s: %+v", name, page)
+		}
+	}
+
+	// Test 2: Article after a deleted entry, by title with spaces
+	page, err := scraper.GetPage("Polar bear")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(page.Sections) != 1 || page.Sections[0].Content != "White bears." {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+
+	// Test 3: Deleted and missing articles
+	for _, name := range []string{"Cub", "Cheesebiscuit"} {
+		_, err := scraper.GetPage(name)
+		if _, ok := err.(*zim.NotFoundError); !ok {
+			t.Errorf("Expected a NotFoundError for %s, got %v", name, err)
+		}
+	}
+}
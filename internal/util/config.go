@@ -0,0 +1,152 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Config is the content of the wikiscrape config file, which adds wikis to
+// the built in list of supported wikis, along with any credentials needed
+// to read them.
+//
+//	{
+//	  "wikis": [{
+//	    "alias": "docs",
+//	    "name": "Team Docs",
+//	    "backend": "wikijs",
+//	    "api": "https://docs.example.com/graphql",
+//	    "host": "docs.example.com",
+//	    "pagePrefix": "/",
+//	    "tokenEnv": "DOCS_TOKEN"
+//	  }]
+//	}
+type Config struct {
+	Wikis []*WikiConfig `json:"wikis"`
+}
+
+// WikiConfig describes a single wiki added through the config file. The
+// wiki is registered under Alias for use with the --wiki flag, and under
+// Host, when set, for use with page URLs. The API token is read from
+// Token, or from the environment variable named by TokenEnv so that it
+// need not be stored in the file. User is only needed by backends which
+// pair the token with a user name, such as Confluence Cloud.
+type WikiConfig struct {
+	Alias      string `json:"alias"`
+	Name       string `json:"name"`
+	Backend    string `json:"backend"`
+	API        string `json:"api"`
+	Host       string `json:"host"`
+	PagePrefix string `json:"pagePrefix"`
+	User       string `json:"user"`
+	Token      string `json:"token"`
+	TokenEnv   string `json:"tokenEnv"`
+	Locale     string `json:"locale"`
+}
+
+// ConfigError indicates that the config file is invalid.
+type ConfigError struct {
+	Code string
+	Info string
+}
+
+// Error returns a formatted ConfigError including code and
+// additional information.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("ConfigError: [code] %s [info] %s", e.Code, e.Info)
+}
+
+// DefaultConfigPath returns the default location of the config file, in the
+// user's config directory. Returns an empty string if there is none.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wikiscrape", "config.json")
+}
+
+// LoadConfig reads the config file at path and registers the wikis it
+// describes. A missing file is ignored unless required is set.
+//
+// Can error when:
+//   - The file cannot be read or is not valid JSON
+//   - A wiki is missing its alias, backend or api, or has an unsupported backend
+func LoadConfig(path string, required bool) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return &ConfigError{
+			Code: "invalidconfig",
+			Info: fmt.Sprintf("The config file %s is not valid JSON: %v", path, err),
+		}
+	}
+	for _, wiki := range config.Wikis {
+		if err := registerWiki(wiki); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerWiki adds a wiki described in the config file to the supported
+// wikis, replacing any built in wiki with the same alias or host.
+func registerWiki(wiki *WikiConfig) error {
+	if wiki.Alias == "" || wiki.Backend == "" || wiki.API == "" {
+		return &ConfigError{
+			Code: "invalidwiki",
+			Info: fmt.Sprintf("The configured wiki %q must have an alias, backend and api", wiki.Alias),
+		}
+	}
+	backend := TrimLower(wiki.Backend)
+	if !isSupportedBackend(backend) {
+		return &ConfigError{
+			Code: "invalidwiki",
+			Info: fmt.Sprintf("The configured wiki %q has unsupported backend %s", wiki.Alias, wiki.Backend),
+		}
+	}
+	name, prefix := wiki.Name, wiki.PagePrefix
+	if name == "" {
+		name = wiki.Alias
+	}
+	if prefix == "" {
+		prefix = "/"
+	}
+	info := newWikiInfo(name, wiki.API, prefix, backend)
+	info.User = wiki.User
+	info.Token = wiki.Token
+	if info.Token == "" && wiki.TokenEnv != "" {
+		info.Token = os.Getenv(wiki.TokenEnv)
+	}
+	info.Locale = wiki.Locale
+	wikiNameInfo[wiki.Alias] = info
+	host := wiki.Host
+	if host == "" {
+		if apiURL, err := url.Parse(wiki.API); err == nil {
+			host = apiURL.Host
+		}
+	}
+	if host != "" {
+		wikiHostInfo[host] = info
+	}
+	return nil
+}
+
+// isSupportedBackend reports whether backend is one of the supported backends.
+func isSupportedBackend(backend string) bool {
+	for _, b := range supportedBackends {
+		if b == backend {
+			return true
+		}
+	}
+	return false
+}
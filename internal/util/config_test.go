@@ -0,0 +1,51 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// Test 1: Missing file is only an error when required
+	missing := filepath.Join(dir, "missing.json")
+	if err := util.LoadConfig(missing, false); err != nil {
+		t.Errorf("Expected no error for missing optional config, got %v", err)
+	}
+	if err := util.LoadConfig(missing, true); err == nil {
+		t.Error("Expected an error for missing required config, got nil")
+	}
+
+	// Test 2: Wikis are registered by alias and host, with the token from the environment
+	t.Setenv("TEST_WIKIJS_TOKEN", "secret")
+	path := filepath.Join(dir, "config.json")
+	config := `{"wikis": [{"alias": "teamdocs", "name": "Team Docs", "backend": "wikijs",
+		"api": "https://docs.example.com/graphql", "tokenEnv": "TEST_WIKIJS_TOKEN"}]}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := util.LoadConfig(path, true); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	got, err := util.GetQueryDataFromName("ops/deployment", "teamdocs")
+	if err != nil {
+		t.Fatalf("Failed to get query data for configured wiki: %v", err)
+	}
+	if got.Info.Backend != "wikijs" || got.Info.Token != "secret" || got.Info.APIPath != "https://docs.example.com/graphql" {
+		t.Errorf("Unexpected wiki info: %+v", got.Info)
+	}
+	got, err = util.GetQueryDataFromURL("https://docs.example.com/ops/deployment")
+	if err != nil || got.Page != "ops/deployment" {
+		t.Errorf("Failed to get query data for configured host: %v, %v", got, err)
+	}
+
+	// Test 3: Unsupported backend
+	os.WriteFile(path, []byte(`{"wikis": [{"alias": "x", "backend": "gopher", "api": "x"}]}`), 0o644)
+	if _, ok := util.LoadConfig(path, true).(*util.ConfigError); !ok {
+		t.Error("Expected a ConfigError for an unsupported backend")
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"os"
 
 	jsoniter "github.com/json-iterator/go"
@@ -11,7 +12,8 @@ var json = jsoniter.ConfigCompatibleWithStandardLibrary
 type Manifest []string
 
 // ReadManifestFrom accepts a path to a JSON file containing
-// an array of page names and reads its content.
+// an array of page names and reads its content. A manifest
+// listing no pages is an error.
 func ReadManifestFrom(filepath string) (Manifest, error) {
 	manFile, err := os.ReadFile(filepath)
 	if err != nil {
@@ -22,5 +24,21 @@ func ReadManifestFrom(filepath string) (Manifest, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(man) == 0 {
+		return nil, fmt.Errorf("the manifest %s lists no pages", filepath)
+	}
 	return man, nil
 }
+
+// WriteManifestTo writes a list of page names to a JSON file at the
+// provided path in the format read by ReadManifestFrom.
+func WriteManifestTo(filepath string, man Manifest) error {
+	if man == nil {
+		man = Manifest{}
+	}
+	content, err := json.MarshalIndent(man, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath, append(content, '\n'), 0644)
+}
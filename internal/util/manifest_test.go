@@ -49,4 +49,43 @@ func TestReadManifestFrom(t *testing.T) {
 	if err == nil {
 		t.Error("Expected an error for invalid JSON file, but got nil")
 	}
+
+	// Test 4: Empty manifest
+	emptyFile := filepath.Join(tmpDir, "empty.json")
+	if err := os.WriteFile(emptyFile, []byte("[]"), 0644); err != nil {
+		t.Fatalf("Failed to create temporary empty JSON file: %v", err)
+	}
+	if _, err := util.ReadManifestFrom(emptyFile); err == nil {
+		t.Error("Expected an error for an empty manifest, but got nil")
+	}
+}
+
+func TestWriteManifestTo(t *testing.T) {
+	tmpDir := t.TempDir()
+	jsonFile := filepath.Join(tmpDir, "manifest.json")
+
+	// Test 1: Round trip
+	want := util.Manifest{"Dragon slayer I", "Cook's Assistant"}
+	err := util.WriteManifestTo(jsonFile, want)
+	if err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	got, err := util.ReadManifestFrom(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read written manifest: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Manifest length mismatch. Got: %d, Want: %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Manifest content mismatch at index %d. Got: %s, Want: %s", i, got[i], want[i])
+		}
+	}
+
+	// Test 2: Unwritable path
+	err = util.WriteManifestTo(filepath.Join(tmpDir, "missing", "manifest.json"), want)
+	if err == nil {
+		t.Error("Expected an error for an unwritable path, but got nil")
+	}
 }
@@ -1,9 +1,63 @@
 package util
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 func TrimLower(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
 }
+
+// NormalizeTitle converts a page name to the canonical form used by
+// MediaWiki: underscores become spaces and the first letter is uppercase.
+// A blank title stays empty.
+func NormalizeTitle(title string) string {
+	title = strings.TrimSpace(strings.ReplaceAll(title, "_", " "))
+	if title == "" {
+		return ""
+	}
+	r, size := utf8.DecodeRuneInString(title)
+	return string(unicode.ToUpper(r)) + title[size:]
+}
+
+// CompilePatterns compiles each expression as a case-insensitive
+// regular expression, as used by the pattern flags of the cli.
+func CompilePatterns(exprs []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// Layouts accepted by ParseTimestamp, most specific first.
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimestamp parses a timestamp given on the command line, either in
+// RFC 3339 format ("2024-01-31T12:00:00Z") or as a date and optional time,
+// which are read as UTC ("2024-01-31" is the start of that day).
+func ParseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q, expected a date such as 2024-01-31 or an RFC 3339 time such as 2024-01-31T12:00:00Z", s)
+}
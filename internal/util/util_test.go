@@ -3,6 +3,7 @@ package util_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/mal0ner/wikiscrape/internal/util"
 )
@@ -29,3 +30,56 @@ func TestTrimLower(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeTitle(t *testing.T) {
+	cases := []utilTestCase{
+		{"dragon_slayer_I", "Dragon slayer I"},
+		{" Dragon slayer I ", "Dragon slayer I"},
+		{"éclair", "Éclair"},
+		{"iPhone", "IPhone"},
+		{"_", ""},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("normalize(%s)=%s", tc.Input, tc.Want), func(t *testing.T) {
+			got := util.NormalizeTitle(tc.Input)
+			if tc.Want != got {
+				t.Errorf("Expected %s, got %s", tc.Want, got)
+			}
+		})
+	}
+}
+
+func TestCompilePatterns(t *testing.T) {
+	// Test 1: Case-insensitive patterns
+	patterns, err := util.CompilePatterns([]string{"^dragon", "slayer$"})
+	if err != nil {
+		t.Fatalf("Failed to compile valid patterns: %v", err)
+	}
+	if !patterns[0].MatchString("Dragon Slayer") || !patterns[1].MatchString("DRAGON SLAYER") {
+		t.Error("Expected patterns to match case-insensitively")
+	}
+
+	// Test 2: Invalid pattern
+	_, err = util.CompilePatterns([]string{"dragon", "("})
+	if err == nil {
+		t.Error("Expected an error for an invalid pattern, but got nil")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := map[string]time.Time{
+		"2024-01-31":                time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		"2024-01-31 12:30":          time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC),
+		"2024-01-31T12:30:15Z":      time.Date(2024, 1, 31, 12, 30, 15, 0, time.UTC),
+		"2024-01-31T12:30:15+02:00": time.Date(2024, 1, 31, 10, 30, 15, 0, time.UTC),
+	}
+	for input, want := range tests {
+		got, err := util.ParseTimestamp(input)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("Timestamp mismatch for %s. Got: %v, %v, Want: %v", input, got, err, want)
+		}
+	}
+	if _, err := util.ParseTimestamp("yesterday"); err == nil {
+		t.Error("Expected an error for an invalid timestamp, got nil")
+	}
+}
@@ -3,6 +3,11 @@ package util
 import (
 	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +18,11 @@ type wikiInfo struct {
 	APIPath        string
 	PagePathPrefix string
 	Backend        string
+	// Credentials and options for backends which need them, usually
+	// provided through the config file.
+	User   string
+	Token  string
+	Locale string
 }
 
 // QueryData represents all the information the
@@ -21,28 +31,106 @@ type wikiInfo struct {
 type QueryData struct {
 	Info *wikiInfo
 	Page string
+	// Section is the heading named by the fragment of a page url, if any.
+	Section string
+	// Revision and PageID are set when a MediaWiki url names a page by
+	// revision (oldid) or page id (curid).
+	Revision int
+	PageID   int
 }
 
 // newWikiInfo initializes a new wikiInfo object, which represents the basic information
 // needed to add support for querying a wiki's API.
 func newWikiInfo(name string, apiPath string, pagePrefix string, backend string) *wikiInfo {
-	return &wikiInfo{name, apiPath, pagePrefix, backend}
+	return &wikiInfo{Name: name, APIPath: apiPath, PagePathPrefix: pagePrefix, Backend: backend}
 }
 
 // Map supported wiki names to relevant query info
 var wikiNameInfo = map[string]*wikiInfo{
-	"wikipedia": newWikiInfo("Wikipedia", "https://en.wikipedia.org/w/api.php", "/wiki/", "mediawiki"),
-	"osrs":      newWikiInfo("Old School Runescape", "https://oldschool.runescape.wiki/api.php", "/w/", "mediawiki"),
+	"wikipedia":  newWikiInfo("Wikipedia", "https://en.wikipedia.org/w/api.php", "/wiki/", "mediawiki"),
+	"wiktionary": newWikiInfo("Wiktionary", "https://en.wiktionary.org/w/api.php", "/wiki/", "mediawiki"),
+	"wikivoyage": newWikiInfo("Wikivoyage", "https://en.wikivoyage.org/w/api.php", "/wiki/", "mediawiki"),
+	"osrs":       newWikiInfo("Old School Runescape", "https://oldschool.runescape.wiki/api.php", "/w/", "mediawiki"),
+	// Wikipedia through the Wikimedia REST API, which serves Parsoid HTML and page summaries
+	"wikipedia-rest": newWikiInfo("Wikipedia (REST)", "https://en.wikipedia.org/api/rest_v1", "/wiki/", "mediawikirest"),
+	"dokuwiki":       newWikiInfo("DokuWiki", "https://www.dokuwiki.org/doku.php", "/", "dokuwiki"),
 }
 
 // Map supported wiki hosts to relevant query info
 var wikiHostInfo = map[string]*wikiInfo{
 	"en.wikipedia.org":         newWikiInfo("Wikipedia", "https://en.wikipedia.org/w/api.php", "/wiki/", "mediawiki"),
 	"oldschool.runescape.wiki": newWikiInfo("Old School Runescape", "https://oldschool.runescape.wiki/api.php", "/w/", "mediawiki"),
+	"www.dokuwiki.org":         newWikiInfo("DokuWiki", "https://www.dokuwiki.org/doku.php", "/", "dokuwiki"),
 }
 
+// Wikimedia projects mapped to their display names. Every language edition
+// of a project is served from "<lang>.<project>.org", or "<lang>.m.<project>.org"
+// on mobile, and matched through wikiHostPatterns.
+var wikimediaProjects = map[string]string{
+	"wikipedia":   "Wikipedia",
+	"wiktionary":  "Wiktionary",
+	"wikivoyage":  "Wikivoyage",
+	"wikibooks":   "Wikibooks",
+	"wikiquote":   "Wikiquote",
+	"wikisource":  "Wikisource",
+	"wikinews":    "Wikinews",
+	"wikiversity": "Wikiversity",
+}
+
+// hostPattern matches the hosts of a family of wikis, such as every language
+// edition of Wikipedia. The "*" in Pattern matches a single host label, the
+// language code, which replaces the "*" in the API path of Info.
+type hostPattern struct {
+	Pattern string
+	Info    *wikiInfo
+}
+
+// Host patterns of wiki families, checked when a host has no exact entry in
+// wikiHostInfo.
+var wikiHostPatterns []*hostPattern
+
+func init() {
+	for project, name := range wikimediaProjects {
+		info := newWikiInfo(name, "https://*."+project+".org/w/api.php", "/wiki/", "mediawiki")
+		wikiHostPatterns = append(wikiHostPatterns,
+			&hostPattern{Pattern: "*." + project + ".org", Info: info},
+			&hostPattern{Pattern: "*.m." + project + ".org", Info: info},
+		)
+	}
+}
+
+// Language selects the language edition of wikis looked up by name, for
+// wikis published in several languages such as Wikipedia. When empty, each
+// wiki's default edition is used.
+var Language string
+
+// Valid language codes, as used in Wikimedia and Fandom hosts and paths.
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]+)*$`)
+
+// Host suffix shared by every Fandom wiki.
+const fandomHostSuffix = ".fandom.com"
+
 var supportedBackends = []string{
 	"mediawiki",
+	"mediawikirest",
+	"dokuwiki",
+	"wikijs",
+	"confluence",
+	"fandom",
+	"gollum",
+	"tiddlywiki",
+	"xmldump",
+	"zim",
+}
+
+// File name suffixes of local wiki files mapped to the backend that reads them.
+var localWikiSuffixes = map[string]string{
+	".xml":     "xmldump",
+	".xml.bz2": "xmldump",
+	".xml.gz":  "xmldump",
+	".zim":     "zim",
+	".html":    "tiddlywiki",
+	".htm":     "tiddlywiki",
 }
 
 // Custom error designed indicate to the user that the
@@ -63,12 +151,119 @@ func (e *WikiNotSupportedError) Error() string {
 // support (existing wikiInfo entry in the wikiHostInfo map) and returns a QueryData
 // object which provides all the necessary information to make a query to the wiki's api.
 func GetQueryDataFromURL(rawURL string) (*QueryData, error) {
+	rawURL, fragment, _ := strings.Cut(rawURL, "#")
 	parsedURL, err := url.ParseRequestURI(rawURL)
 	if err != nil {
 		return nil, err
 	}
-	if info, ok := wikiHostInfo[parsedURL.Host]; ok {
-		pageName, err := getPageNameFromPath(parsedURL.Path, info.PagePathPrefix)
+	info, ok := wikiInfoFromHost(parsedURL.Host)
+	if !ok {
+		info, ok = fandomWikiInfo(parsedURL.Host, fandomLanguage(parsedURL.Path))
+	}
+	if !ok {
+		return nil, &WikiNotSupportedError{
+			Code: "hostnotfound",
+			Info: "The provided host is not yet supported (unknown api endpoint or page prefix)",
+		}
+	}
+	queryData := &QueryData{Info: info}
+	if fragment, err := url.PathUnescape(fragment); err == nil {
+		queryData.Section = strings.TrimSpace(strings.ReplaceAll(fragment, "_", " "))
+	}
+	if info.Backend == "mediawiki" || info.Backend == "fandom" {
+		return queryData, queryData.parseMediaWikiQuery(parsedURL)
+	}
+	if info.Backend == "dokuwiki" {
+		return queryData, queryData.parseDokuWikiQuery(parsedURL)
+	}
+	queryData.Page, err = getPageNameFromPath(parsedURL.Path, info.PagePathPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return queryData, nil
+}
+
+// parseMediaWikiQuery reads the page named by a MediaWiki url into the
+// QueryData. Besides article paths ("/wiki/Bear"), MediaWiki urls can name a
+// page through the query string of index.php: by title ("?title=Bear"),
+// revision ("?oldid=123") or page id ("?curid=456"). Searches
+// ("Special:Search?search=Bear") name the page searched for. Revisions and
+// page ids are requested through Special:Redirect, which the MediaWiki
+// scraper resolves.
+func (queryData *QueryData) parseMediaWikiQuery(parsedURL *url.URL) error {
+	query := parsedURL.Query()
+	queryData.Page = query.Get("title")
+	if queryData.Page == "" {
+		queryData.Page, _ = getPageNameFromPath(parsedURL.Path, queryData.Info.PagePathPrefix)
+	}
+	if search := query.Get("search"); search != "" && isSpecialSearch(queryData.Page) {
+		queryData.Page = search
+	}
+	for param, id := range map[string]*int{"oldid": &queryData.Revision, "curid": &queryData.PageID} {
+		if value := query.Get(param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid %s in url: %s", param, value)
+			}
+			*id = n
+		}
+	}
+	switch {
+	case queryData.Revision != 0:
+		queryData.Page = RevisionPage(queryData.Revision)
+	case queryData.PageID != 0:
+		queryData.Page = PageIDPage(queryData.PageID)
+	case queryData.Page == "":
+		return fmt.Errorf("page path does not start with expected prefix")
+	}
+	return nil
+}
+
+// parseDokuWikiQuery reads the page named by a DokuWiki url into the
+// QueryData. Besides rewritten paths ("/syntax"), DokuWiki urls name a page
+// through the id parameter of doku.php ("/doku.php?id=syntax"), or the path
+// following it ("/doku.php/syntax").
+func (queryData *QueryData) parseDokuWikiQuery(parsedURL *url.URL) error {
+	page, err := getPageNameFromPath(parsedURL.Path, queryData.Info.PagePathPrefix)
+	if err != nil {
+		return err
+	}
+	if page == "doku.php" {
+		page = parsedURL.Query().Get("id")
+	} else if rest, ok := strings.CutPrefix(page, "doku.php/"); ok {
+		page = rest
+	}
+	if page == "" {
+		return fmt.Errorf("url does not name a page, expected an id parameter")
+	}
+	queryData.Page = page
+	return nil
+}
+
+// isSpecialSearch reports whether a page name is the search special page.
+func isSpecialSearch(page string) bool {
+	return strings.EqualFold(strings.ReplaceAll(page, " ", "_"), "Special:Search")
+}
+
+// RevisionPage returns the name of the MediaWiki special page redirecting
+// to a revision. MediaWiki scrapers fetch that revision when given it.
+func RevisionPage(revision int) string {
+	return fmt.Sprintf("Special:Redirect/revision/%d", revision)
+}
+
+// PageIDPage returns the name of the MediaWiki special page redirecting to
+// the page with the given id. MediaWiki scrapers fetch that page when given it.
+func PageIDPage(id int) string {
+	return fmt.Sprintf("Special:Redirect/page/%d", id)
+}
+
+// GetQueryDataFromName accepts a page name and wikiname, checks for explicit support
+// (existing wikiInfo entry in the wikiNameInfo map, or a path to a local wiki file)
+// and returns a QueryData object which provides all the necessary information to make
+// a query to the wiki's api.
+func GetQueryDataFromName(pageName string, wikiName string) (*QueryData, error) {
+	if info, ok := wikiNameInfo[wikiName]; ok {
+		info, err := localizeWikiInfo(info, Language)
 		if err != nil {
 			return nil, err
 		}
@@ -77,17 +272,18 @@ func GetQueryDataFromURL(rawURL string) (*QueryData, error) {
 			Info: info,
 		}, nil
 	}
-	return nil, &WikiNotSupportedError{
-		Code: "hostnotfound",
-		Info: "The provided host is not yet supported (unknown api endpoint or page prefix)",
+	if host, lang, _ := strings.Cut(wikiName, "/"); strings.HasSuffix(host, fandomHostSuffix) {
+		if lang == "" && Language != "en" {
+			lang = Language
+		}
+		if info, ok := fandomWikiInfo(host, lang); ok {
+			return &QueryData{
+				Page: pageName,
+				Info: info,
+			}, nil
+		}
 	}
-}
-
-// GetQueryDataFromName accepts a page name and wikiname, checks for explicit support
-// (existing wikiInfo entry in the wikiNameInfo map) and returns a QueryData object
-// which provides all the necessary information to make a query to the wiki's api.
-func GetQueryDataFromName(pageName string, wikiName string) (*QueryData, error) {
-	if info, ok := wikiNameInfo[wikiName]; ok {
+	if info, ok := localWikiInfo(wikiName); ok {
 		return &QueryData{
 			Page: pageName,
 			Info: info,
@@ -99,6 +295,134 @@ func GetQueryDataFromName(pageName string, wikiName string) (*QueryData, error)
 	}
 }
 
+// GetQueryData accepts either a raw wiki url, or a page name when a wikiName is
+// provided, and returns the QueryData for it. This supports commands which accept
+// "<url|page -w wiki>" style arguments.
+func GetQueryData(arg string, wikiName string) (*QueryData, error) {
+	if wikiName != "" {
+		return GetQueryDataFromName(arg, wikiName)
+	}
+	return GetQueryDataFromURL(arg)
+}
+
+// localWikiInfo returns the wikiInfo for a wiki stored in a local file, such as an
+// XML dump, when path names an existing file with a recognised suffix, or for a
+// Git backed wiki when path names a clone of its repository. The path is stored
+// in place of the API endpoint.
+func localWikiInfo(path string) (*wikiInfo, bool) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if stat.IsDir() {
+		if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+			return nil, false
+		}
+		return newWikiInfo(filepath.Base(filepath.Clean(path)), path, "", "gollum"), true
+	}
+	for suffix, backend := range localWikiSuffixes {
+		if strings.HasSuffix(strings.ToLower(path), suffix) {
+			return newWikiInfo(filepath.Base(path), path, "", backend), true
+		}
+	}
+	return nil, false
+}
+
+// wikiInfoFromHost returns the wikiInfo for a host with an exact entry in
+// wikiHostInfo, or which matches one of wikiHostPatterns.
+func wikiInfoFromHost(host string) (*wikiInfo, bool) {
+	if info, ok := wikiHostInfo[host]; ok {
+		return info, true
+	}
+	for _, pattern := range wikiHostPatterns {
+		if lang, ok := matchHostPattern(pattern.Pattern, host); ok {
+			info := *pattern.Info
+			info.Name = fmt.Sprintf("%s (%s)", info.Name, lang)
+			info.APIPath = strings.Replace(info.APIPath, "*", lang, 1)
+			return &info, true
+		}
+	}
+	return nil, false
+}
+
+// matchHostPattern reports whether host matches pattern, returning the host
+// label matched by the pattern's "*". The label must be a language code, so
+// portals such as "www.wikipedia.org" and "m.wikipedia.org" do not match.
+func matchHostPattern(pattern string, host string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	if len(host) <= len(prefix)+len(suffix) || !strings.HasPrefix(host, prefix) || !strings.HasSuffix(host, suffix) {
+		return "", false
+	}
+	label := host[len(prefix) : len(host)-len(suffix)]
+	if label == "www" || !languageCode.MatchString(label) {
+		return "", false
+	}
+	return label, true
+}
+
+// localizeWikiInfo returns the wikiInfo for the lang language edition of a
+// wiki whose API host matches one of wikiHostPatterns, such as Wikipedia.
+// The API path is kept apart from its host, so both the action API and the
+// REST API of a wiki can be localized. An empty lang returns info unchanged.
+//
+// Can error when:
+//   - lang is not a valid language code
+//   - The wiki is not published in several languages
+func localizeWikiInfo(info *wikiInfo, lang string) (*wikiInfo, error) {
+	if lang == "" {
+		return info, nil
+	}
+	if !languageCode.MatchString(lang) {
+		return nil, &WikiNotSupportedError{
+			Code: "invalidlang",
+			Info: fmt.Sprintf("%s is not a valid language code, such as de or pt-br", lang),
+		}
+	}
+	apiURL, err := url.Parse(info.APIPath)
+	if err == nil {
+		for _, pattern := range wikiHostPatterns {
+			if _, ok := matchHostPattern(pattern.Pattern, apiURL.Host); ok {
+				localized := *info
+				apiURL.Host = strings.Replace(pattern.Pattern, "*", lang, 1)
+				localized.APIPath = apiURL.String()
+				localized.Name = fmt.Sprintf("%s (%s)", info.Name, lang)
+				return &localized, nil
+			}
+		}
+	}
+	return nil, &WikiNotSupportedError{
+		Code: "langnotsupported",
+		Info: fmt.Sprintf("The wiki %s is not available in other languages", info.Name),
+	}
+}
+
+// fandomWikiInfo returns the wikiInfo for a Fandom wiki, any subdomain of
+// fandom.com, given its host and language code. Wikis in languages other than
+// English are served under a language path, such as
+// "https://minecraft.fandom.com/de/wiki/Steve".
+func fandomWikiInfo(host string, lang string) (*wikiInfo, bool) {
+	wiki := strings.TrimSuffix(host, fandomHostSuffix)
+	if !strings.HasSuffix(host, fandomHostSuffix) || wiki == "" || wiki == "www" {
+		return nil, false
+	}
+	base, name := "https://"+host, wiki+" (Fandom)"
+	if lang != "" {
+		base += "/" + lang
+		name = fmt.Sprintf("%s (Fandom, %s)", wiki, lang)
+	}
+	return newWikiInfo(name, base+"/api.php", strings.TrimPrefix(base, "https://"+host)+"/wiki/", "fandom"), true
+}
+
+// fandomLanguage returns the language code at the start of the path of a
+// Fandom page url, or an empty string for English wikis.
+func fandomLanguage(path string) string {
+	lang, rest, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok || lang == "wiki" || !strings.HasPrefix(rest, "wiki/") {
+		return ""
+	}
+	return lang
+}
+
 // getPageNameFromPath strips a prefix from the beginning of a string. In this
 // use case, it is designed to take a url.URL.path from a parsed wiki URL and
 // remove the page prefix so as to return the full page name. This function
@@ -128,24 +452,23 @@ func GetSupportedBackends() []string {
 }
 
 // GetWikiInfoStrings returns a formatted slice of strings containing relevant
-// information about the wikis supported by wikiscrape. This function is designed for
-// use with the List command.
+// information about the wikis supported by wikiscrape, sorted by alias. This
+// function is designed for use with the List command.
 func GetWikiInfoStrings(backendFilter string) []string {
-	items := make([]string, len(wikiNameInfo))
-	i := 0
+	var items []string
 	for k, v := range wikiNameInfo {
 		if v.Backend == backendFilter || backendFilter == "" {
-			items[i] = fmt.Sprintf("%s: [alias: %s, backend: %s]", v.Name, k, v.Backend)
+			items = append(items, fmt.Sprintf("%s: [alias: %s, backend: %s]", v.Name, k, v.Backend))
 		}
-		i++
 	}
+	sort.Strings(items)
 	return items
 }
 
 // GetWikiInfoFromHost takes a URL host segment and returns its corresponding wikiInfo.
 // Fails if the wiki is not supported by wikiscrape.
 func GetWikiInfoFromHost(host string) (*wikiInfo, error) {
-	info, ok := wikiHostInfo[host]
+	info, ok := wikiInfoFromHost(host)
 	if !ok {
 		return nil, &WikiNotSupportedError{
 			Code: "hostnotfound",
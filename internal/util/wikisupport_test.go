@@ -1,6 +1,8 @@
 package util_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/mal0ner/wikiscrape/internal/util"
@@ -46,3 +48,221 @@ func TestGetQueryDataFromName(t *testing.T) {
 		t.Errorf("Expected an error for invalid wiki name, got nil")
 	}
 }
+
+func TestGetQueryData(t *testing.T) {
+	// Test 1: URL without wiki name
+	got, err := util.GetQueryData("https://oldschool.runescape.wiki/w/Dragon_slayer_I", "")
+	if err != nil {
+		t.Fatalf("Failed to generate query data from valid url: %v", err)
+	}
+	if got.Page != "Dragon_slayer_I" {
+		t.Errorf("Failed to parse page name from URL, Got: %s, Want: %s", got.Page, "Dragon_slayer_I")
+	}
+
+	// Test 2: Page name with wiki name
+	got, err = util.GetQueryData("Dragon slayer I", "osrs")
+	if err != nil {
+		t.Fatalf("Failed to generate query data from valid name: %v", err)
+	}
+	if got.Page != "Dragon slayer I" || got.Info.Backend != "mediawiki" {
+		t.Errorf("Unexpected query data for named page: %+v", got)
+	}
+
+	// Test 3: Page name without wiki name is treated as a URL
+	_, err = util.GetQueryData("Dragon slayer I", "")
+	if err == nil {
+		t.Error("Expected an error for a page name without a wiki, but got nil")
+	}
+}
+
+func TestGetQueryDataFromLocalFile(t *testing.T) {
+	dump := filepath.Join(t.TempDir(), "enwiki.xml.bz2")
+	if err := os.WriteFile(dump, nil, 0644); err != nil {
+		t.Fatalf("Failed to create dump: %v", err)
+	}
+
+	// Test 1: Existing dump
+	got, err := util.GetQueryDataFromName("Bear", dump)
+	if err != nil {
+		t.Fatalf("Failed to generate query data from local dump: %v", err)
+	}
+	if got.Info.Backend != "xmldump" || got.Info.APIPath != dump {
+		t.Errorf("Unexpected query data for local dump: %+v", got.Info)
+	}
+
+	// Test 2: Missing dump
+	_, err = util.GetQueryDataFromName("Bear", filepath.Join(t.TempDir(), "missing.xml"))
+	if err == nil {
+		t.Error("Expected an error for a missing dump, but got nil")
+	}
+}
+
+func TestGetWikiInfoStrings(t *testing.T) {
+	// Test 1: Filtering by backend leaves no blank entries
+	for _, s := range util.GetWikiInfoStrings("dokuwiki") {
+		if s == "" {
+			t.Error("Expected no blank entries when filtering by backend")
+		}
+	}
+
+	// Test 2: Unknown backend
+	if got := util.GetWikiInfoStrings("cheesebiscuit"); len(got) != 0 {
+		t.Errorf("Expected no wikis for an unknown backend, got %v", got)
+	}
+}
+
+func TestGetQueryDataFromGitWiki(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project.wiki")
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Test 1: Clone of a Git wiki
+	got, err := util.GetQueryDataFromName("Home", dir)
+	if err != nil {
+		t.Fatalf("Failed to get query data for git wiki: %v", err)
+	}
+	if got.Info.Backend != "gollum" || got.Info.APIPath != dir || got.Info.Name != "project.wiki" {
+		t.Errorf("Unexpected wiki info: %+v", got.Info)
+	}
+
+	// Test 2: Plain directory
+	if _, err := util.GetQueryDataFromName("Home", filepath.Dir(dir)); err == nil {
+		t.Error("Expected an error for a directory which is not a git clone, got nil")
+	}
+}
+
+func TestGetQueryDataFandom(t *testing.T) {
+	tests := []struct {
+		arg, wiki, page, api string
+	}{
+		{"https://minecraft.fandom.com/wiki/Creeper", "", "Creeper", "https://minecraft.fandom.com/api.php"},
+		{"https://minecraft.fandom.com/de/wiki/Creeper", "", "Creeper", "https://minecraft.fandom.com/de/api.php"},
+		{"Creeper", "minecraft.fandom.com", "Creeper", "https://minecraft.fandom.com/api.php"},
+		{"Creeper", "minecraft.fandom.com/de", "Creeper", "https://minecraft.fandom.com/de/api.php"},
+	}
+	for _, test := range tests {
+		got, err := util.GetQueryData(test.arg, test.wiki)
+		if err != nil {
+			t.Errorf("Failed to get query data for %s: %v", test.arg, err)
+			continue
+		}
+		if got.Page != test.page || got.Info.APIPath != test.api || got.Info.Backend != "fandom" {
+			t.Errorf("Unexpected query data for %s %s. Got: %s %+v", test.arg, test.wiki, got.Page, got.Info)
+		}
+	}
+
+	// Fandom's own site is not a wiki
+	if _, err := util.GetQueryDataFromURL("https://www.fandom.com/wiki/Creeper"); err == nil {
+		t.Error("Expected an error for www.fandom.com, got nil")
+	}
+}
+
+func TestGetQueryDataWikimediaHosts(t *testing.T) {
+	tests := []struct {
+		url, page, api string
+	}{
+		{"https://de.wikipedia.org/wiki/B%C3%A4r", "Bär", "https://de.wikipedia.org/w/api.php"},
+		{"https://en.m.wikipedia.org/wiki/Bear", "Bear", "https://en.wikipedia.org/w/api.php"},
+		{"https://fr.wiktionary.org/wiki/ours", "ours", "https://fr.wiktionary.org/w/api.php"},
+		{"https://en.m.wikivoyage.org/wiki/Paris", "Paris", "https://en.wikivoyage.org/w/api.php"},
+	}
+	for _, test := range tests {
+		got, err := util.GetQueryDataFromURL(test.url)
+		if err != nil {
+			t.Errorf("Failed to get query data for %s: %v", test.url, err)
+			continue
+		}
+		if got.Page != test.page || got.Info.APIPath != test.api {
+			t.Errorf("Unexpected query data for %s. Got: %s %+v", test.url, got.Page, got.Info)
+		}
+	}
+
+	// Hosts with extra labels, or labels which are not language codes, do not match
+	for _, rawURL := range []string{
+		"https://a.b.wikipedia.org/wiki/Bear",
+		"https://www.wikipedia.org/wiki/Bear",
+		"https://m.wikipedia.org/wiki/Bear",
+	} {
+		_, err := util.GetQueryDataFromURL(rawURL)
+		if _, ok := err.(*util.WikiNotSupportedError); !ok {
+			t.Errorf("Expected a WikiNotSupportedError for %s, got %v", rawURL, err)
+		}
+	}
+}
+
+func TestGetQueryDataLanguage(t *testing.T) {
+	defer func() { util.Language = "" }()
+
+	// Test 1: Action and REST APIs are localized
+	util.Language = "de"
+	for name, want := range map[string]string{
+		"wikipedia":      "https://de.wikipedia.org/w/api.php",
+		"wikipedia-rest": "https://de.wikipedia.org/api/rest_v1",
+		"wiktionary":     "https://de.wiktionary.org/w/api.php",
+	} {
+		got, err := util.GetQueryDataFromName("Bär", name)
+		if err != nil {
+			t.Errorf("Failed to get query data for %s: %v", name, err)
+			continue
+		}
+		if got.Info.APIPath != want {
+			t.Errorf("API mismatch for %s. Got: %s, Want: %s", name, got.Info.APIPath, want)
+		}
+	}
+
+	// Test 2: Fandom wikis take the language as a path
+	got, err := util.GetQueryDataFromName("Creeper", "minecraft.fandom.com")
+	if err != nil || got.Info.APIPath != "https://minecraft.fandom.com/de/api.php" {
+		t.Errorf("Unexpected query data: %+v, %v", got, err)
+	}
+
+	// Test 3: Single language wikis and invalid codes
+	if _, err := util.GetQueryDataFromName("Dragon", "osrs"); err == nil {
+		t.Error("Expected an error for a single language wiki, got nil")
+	}
+	util.Language = "../x"
+	if _, err := util.GetQueryDataFromName("Bear", "wikipedia"); err == nil {
+		t.Error("Expected an error for an invalid language code, got nil")
+	}
+}
+
+func TestGetQueryDataURLShapes(t *testing.T) {
+	tests := []struct {
+		url, page, section string
+		revision, pageID   int
+	}{
+		{"https://en.wikipedia.org/wiki/Bear#Etymology_and_naming", "Bear", "Etymology and naming", 0, 0},
+		{"https://en.wikipedia.org/w/index.php?title=Bear&action=view", "Bear", "", 0, 0},
+		{"https://en.wikipedia.org/w/index.php?title=Bear&oldid=123", "Special:Redirect/revision/123", "", 123, 0},
+		{"https://en.wikipedia.org/w/index.php?oldid=123#Diet", "Special:Redirect/revision/123", "Diet", 123, 0},
+		{"https://en.wikipedia.org/?curid=456", "Special:Redirect/page/456", "", 0, 456},
+		{"https://en.wikipedia.org/wiki/Special:Search?search=Polar+bear", "Polar bear", "", 0, 0},
+		{"https://en.wikipedia.org/w/index.php?search=Polar+bear&title=Special%3ASearch", "Polar bear", "", 0, 0},
+		{"https://de.wikipedia.org/wiki/B%C3%A4r#Verbreitung_%26_Lebensraum", "Bär", "Verbreitung & Lebensraum", 0, 0},
+		{"https://www.dokuwiki.org/doku.php?id=syntax", "syntax", "", 0, 0},
+		{"https://www.dokuwiki.org/doku.php?id=wiki:syntax#links", "wiki:syntax", "links", 0, 0},
+		{"https://www.dokuwiki.org/doku.php/syntax", "syntax", "", 0, 0},
+		{"https://www.dokuwiki.org/syntax", "syntax", "", 0, 0},
+	}
+	for _, test := range tests {
+		got, err := util.GetQueryDataFromURL(test.url)
+		if err != nil {
+			t.Errorf("Failed to get query data for %s: %v", test.url, err)
+			continue
+		}
+		if got.Page != test.page || got.Section != test.section || got.Revision != test.revision || got.PageID != test.pageID {
+			t.Errorf("Unexpected query data for %s. Got: %+v", test.url, got)
+		}
+	}
+
+	// Invalid revision
+	if _, err := util.GetQueryDataFromURL("https://en.wikipedia.org/w/index.php?oldid=abc"); err == nil {
+		t.Error("Expected an error for an invalid oldid, got nil")
+	}
+
+	// DokuWiki url without a page id
+	if _, err := util.GetQueryDataFromURL("https://www.dokuwiki.org/doku.php?do=recent"); err == nil {
+		t.Error("Expected an error for a url without a page id, got nil")
+	}
+}
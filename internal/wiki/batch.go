@@ -0,0 +1,39 @@
+package wiki
+
+import (
+	"strings"
+
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/logging"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+// scrapeManifestBatched splits a manifest into chunks of the scraper's batch
+// size, fetching each chunk in as few requests as possible before filtering
+// and exporting its pages. Pages missing from the wiki, or without a section
+// matching the filter, are logged and skipped.
+func scrapeManifestBatched(batcher scrape.BatchScraper, exporter export.Exporter, man util.Manifest, filter *scrape.SectionFilter) error {
+	size := batcher.BatchSize()
+	for start := 0; start < len(man); start += size {
+		end := min(start+size, len(man))
+		pages, missing, err := batcher.GetPages(man[start:end])
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			logging.Log.Warnf("%d of %d pages in batch were not found: %s", len(missing), end-start, strings.Join(missing, ", "))
+		}
+		for _, page := range pages {
+			sections, err := filter.Apply(page.Title, page.Sections)
+			if err != nil {
+				logging.Log.Warnf("skipping %s: %v", page.Title, err)
+				continue
+			}
+			page.Sections = sections
+			page.Filter = filter
+			exporter.Export(page)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package wiki
+
+import (
+	"regexp"
+
+	"github.com/mal0ner/wikiscrape/internal/logging"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+// CrawlOptions limits the scope of a crawl. Depth is the number of link
+// hops to follow from the start page and MaxPages caps the number of pages
+// exported (0 means no cap). Only titles matching at least one Include
+// pattern (when any are given) and no Exclude pattern are followed, and
+// only links into Namespaces (the main namespace when empty) are listed.
+type CrawlOptions struct {
+	Depth      int
+	MaxPages   int
+	Include    []*regexp.Regexp
+	Exclude    []*regexp.Regexp
+	Namespaces []int
+}
+
+// NewCrawlOptions builds CrawlOptions from raw command line values.
+// Include and exclude patterns are compiled case-insensitively.
+//
+// Can error when:
+//   - A pattern is not a valid regular expression
+func NewCrawlOptions(depth int, maxPages int, include []string, exclude []string, namespaces []int) (*CrawlOptions, error) {
+	opts := &CrawlOptions{
+		Depth:      depth,
+		MaxPages:   maxPages,
+		Namespaces: namespaces,
+	}
+	var err error
+	opts.Include, err = util.CompilePatterns(include)
+	if err != nil {
+		return nil, err
+	}
+	opts.Exclude, err = util.CompilePatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// follows reports whether a linked title is within the scope of the crawl.
+func (opts *CrawlOptions) follows(title string) bool {
+	for _, re := range opts.Exclude {
+		if re.MatchString(title) {
+			return false
+		}
+	}
+	if len(opts.Include) == 0 {
+		return true
+	}
+	for _, re := range opts.Include {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// crawlItem is a queued page along with its distance from the start page.
+type crawlItem struct {
+	title string
+	depth int
+}
+
+// Crawl performs a breadth first traversal of the internal links of a wiki,
+// starting at the page specified by start. Each page is visited once, by its
+// canonical title, and is scraped and exported through wiki.ScrapeSections
+// exactly as a manifest page would be. Pages that fail to scrape are logged
+// and skipped.
+//
+// Can error when:
+//   - The wiki's scraper cannot list links
+//   - The start page cannot be resolved
+func Crawl(wiki Wiki, start string, opts *CrawlOptions, filter *scrape.SectionFilter) error {
+	lister, ok := wiki.GetScraper().(scrape.LinkLister)
+	if !ok {
+		return &util.WikiNotSupportedError{
+			Code: "crawlnotsupported",
+			Info: "The wiki's backend does not support listing page links",
+		}
+	}
+	queue := []crawlItem{{title: start}}
+	queued := map[string]bool{start: true}
+	visited := map[string]bool{}
+	exported := 0
+	for len(queue) > 0 && (opts.MaxPages <= 0 || exported < opts.MaxPages) {
+		item := queue[0]
+		queue = queue[1:]
+		title, links, err := lister.GetLinks(item.title, opts.Namespaces)
+		if err != nil {
+			if item.depth == 0 {
+				return err
+			}
+			logging.Log.Warnf("skipping %s: %v", item.title, err)
+			continue
+		}
+		if visited[title] {
+			continue
+		}
+		visited[title] = true
+		if err := wiki.ScrapeSections(title, filter); err != nil {
+			logging.Log.Warnf("skipping %s: %v", title, err)
+			continue
+		}
+		exported++
+		if item.depth >= opts.Depth {
+			continue
+		}
+		for _, link := range links {
+			if queued[link] || visited[link] || !opts.follows(link) {
+				continue
+			}
+			queued[link] = true
+			queue = append(queue, crawlItem{title: link, depth: item.depth + 1})
+		}
+	}
+	logging.Log.Infof("crawled %d pages from %s", exported, start)
+	return nil
+}
@@ -0,0 +1,116 @@
+package wiki_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+)
+
+// testLinkScraper serves a fixed link graph. Redirects maps a title to
+// its canonical title.
+type testLinkScraper struct {
+	links     map[string][]string
+	redirects map[string]string
+}
+
+func (s *testLinkScraper) GetPage(path string) (*scrape.Page, error) {
+	return &scrape.Page{Title: path}, nil
+}
+
+func (s *testLinkScraper) GetSections(path string, _ *scrape.SectionFilter) (*scrape.Page, error) {
+	return s.GetPage(path)
+}
+
+func (s *testLinkScraper) GetLinks(path string, _ []int) (string, []string, error) {
+	if canonical, ok := s.redirects[path]; ok {
+		path = canonical
+	}
+	links, ok := s.links[path]
+	if !ok {
+		return "", nil, fmt.Errorf("missing page %s", path)
+	}
+	return path, links, nil
+}
+
+// testWiki records the pages exported by a crawl.
+type testWiki struct {
+	scraper  *testLinkScraper
+	exported []string
+}
+
+func (w *testWiki) ScrapeManifest(util.Manifest, *scrape.SectionFilter, wiki.FetchStrategy) error {
+	return nil
+}
+func (w *testWiki) ScrapeSummary(string) error   { return nil }
+func (w *testWiki) ScrapePage(path string) error { return w.ScrapeSections(path, nil) }
+func (w *testWiki) GetScraper() scrape.Scraper   { return w.scraper }
+
+func (w *testWiki) ScrapeSections(path string, _ *scrape.SectionFilter) error {
+	w.exported = append(w.exported, path)
+	return nil
+}
+
+func newTestWiki() *testWiki {
+	return &testWiki{scraper: &testLinkScraper{
+		links: map[string][]string{
+			"Dragon slayer I": {"Elvarg", "Oziach", "Crandor", "Dragon"},
+			"Elvarg":          {"Dragon slayer I", "Crandor"},
+			"Oziach":          {"Rune platebody", "Missing"},
+			"Crandor":         {"Elvarg"},
+			"Dragons":         {"Elvarg"},
+			"Rune platebody":  {},
+		},
+		redirects: map[string]string{"Dragon": "Dragons"},
+	}}
+}
+
+func TestCrawl(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Depth    int
+		MaxPages int
+		Include  []string
+		Exclude  []string
+		Want     []string
+	}{
+		{"start only", 0, 0, nil, nil, []string{"Dragon slayer I"}},
+		{"one hop", 1, 0, nil, nil, []string{"Dragon slayer I", "Elvarg", "Oziach", "Crandor", "Dragons"}},
+		{"two hops", 2, 0, nil, nil, []string{"Dragon slayer I", "Elvarg", "Oziach", "Crandor", "Dragons", "Rune platebody"}},
+		{"page budget", 2, 3, nil, nil, []string{"Dragon slayer I", "Elvarg", "Oziach"}},
+		{"include", 2, 0, []string{"^(elvarg|crandor)$"}, nil, []string{"Dragon slayer I", "Elvarg", "Crandor"}},
+		{"exclude", 2, 0, nil, []string{"^o", "dragon$"}, []string{"Dragon slayer I", "Elvarg", "Crandor"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			opts, err := wiki.NewCrawlOptions(tc.Depth, tc.MaxPages, tc.Include, tc.Exclude, nil)
+			if err != nil {
+				t.Fatalf("Failed to build crawl options: %v", err)
+			}
+			w := newTestWiki()
+			if err := wiki.Crawl(w, "Dragon slayer I", opts, nil); err != nil {
+				t.Fatalf("Unexpected crawl error: %v", err)
+			}
+			if fmt.Sprint(w.exported) != fmt.Sprint(tc.Want) {
+				t.Errorf("Exported pages mismatch. Got: %v, Want: %v", w.exported, tc.Want)
+			}
+		})
+	}
+}
+
+func TestCrawlErrors(t *testing.T) {
+	// Test 1: Missing start page
+	opts, _ := wiki.NewCrawlOptions(1, 0, nil, nil, nil)
+	err := wiki.Crawl(newTestWiki(), "Cheesebiscuit", opts, nil)
+	if err == nil {
+		t.Error("Expected an error for a missing start page, but got nil")
+	}
+
+	// Test 2: Invalid pattern
+	_, err = wiki.NewCrawlOptions(1, 0, []string{"("}, nil, nil)
+	if err == nil {
+		t.Error("Expected an error for an invalid pattern, but got nil")
+	}
+}
@@ -3,6 +3,7 @@ package wiki
 import (
 	"github.com/mal0ner/wikiscrape/internal/export"
 	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
 	"github.com/mal0ner/wikiscrape/internal/util"
 )
 
@@ -19,20 +20,39 @@ type MediaWiki struct {
 
 // NewMediaWiki instantiates a new Media Wiki with a provided name and
 // base url, as well as sensible defaults for the scraper and exporter.
+// The scraper uses cache.Default to store API responses, and pages are
+// exported with export.Default.
 func NewMediaWiki(name string, baseURL string) Wiki {
 	return &MediaWiki{
 		Name:     name,
 		BaseURL:  baseURL,
-		Scraper:  &scrape.MediaWikiScraper{BaseURL: baseURL},
-		Exporter: &export.TestExporter{},
+		Scraper:  &scrape.MediaWikiScraper{BaseURL: baseURL, Cache: cache.Default},
+		Exporter: export.Default,
 	}
 }
 
-// ScrapeAndExport loops over a util.Manifest ([]string) list of page
-// names, scraping and then exporting each page sequentially.
-func (wiki *MediaWiki) ScrapeManifest(man util.Manifest) error {
+// NewFandom instantiates a Media Wiki for a Fandom wiki, whose scraper
+// removes Fandom specific markup and parses portable infoboxes.
+func NewFandom(name string, baseURL string) Wiki {
+	return &MediaWiki{
+		Name:     name,
+		BaseURL:  baseURL,
+		Scraper:  scrape.NewFandomScraper(baseURL, cache.Default),
+		Exporter: export.Default,
+	}
+}
+
+// ScrapeManifest loops over a util.Manifest ([]string) list of page
+// names, scraping and then exporting each page sequentially. Only the
+// sections selected by the filter are exported; pages without a
+// matching section are skipped. With the FetchBatch strategy pages
+// are fetched in batches when the scraper supports it.
+func (wiki *MediaWiki) ScrapeManifest(man util.Manifest, filter *scrape.SectionFilter, strategy FetchStrategy) error {
+	if batcher, ok := wiki.Scraper.(scrape.BatchScraper); ok && strategy == FetchBatch {
+		return scrapeManifestBatched(batcher, wiki.Exporter, man, filter)
+	}
 	for _, path := range man {
-		page, err := wiki.GetPage(path)
+		page, err := wiki.GetSections(path, filter)
 		if err != nil {
 			continue
 		}
@@ -53,14 +73,26 @@ func (wiki *MediaWiki) ScrapePage(path string) error {
 	return nil
 }
 
-// Section provides a convenient wrapper around the wiki's
-// scraper and exporter. Fetches, parses, and exports a single
-// section of a single page given its name.
-func (wiki *MediaWiki) ScrapeSection(path string, heading string) error {
-	page, err := wiki.GetSection(path, heading)
+// ScrapeSections provides a convenient wrapper around the wiki's
+// scraper and exporter. Fetches, parses, and exports the sections
+// of a single page selected by the filter.
+func (wiki *MediaWiki) ScrapeSections(path string, filter *scrape.SectionFilter) error {
+	page, err := wiki.GetSections(path, filter)
 	if err != nil {
 		return err
 	}
 	wiki.Export(page)
 	return nil
 }
+
+// ScrapeSummary fetches and exports the summary of a single page
+// given its name, when the wiki's scraper supports summaries.
+func (wiki *MediaWiki) ScrapeSummary(path string) error {
+	return scrapeSummary(wiki.Scraper, wiki.Exporter, path)
+}
+
+// GetScraper returns the scraper used by the wiki, allowing callers to
+// check for optional backend capabilities such as scrape.TOCScraper.
+func (wiki *MediaWiki) GetScraper() scrape.Scraper {
+	return wiki.Scraper
+}
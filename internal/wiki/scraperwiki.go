@@ -0,0 +1,33 @@
+package wiki
+
+import (
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+// ScraperWiki represents a "Wiki" interface compliant implementation
+// that pairs the scraper of any backend with an exporter. Backends that
+// need no behaviour beyond scraping and exporting, such as local dump
+// files, are added by providing a scrape.Scraper. Pages are scraped and
+// exported as by MediaWiki.
+type ScraperWiki struct {
+	MediaWiki
+}
+
+// NewScraperWiki instantiates a new ScraperWiki with a provided name and
+// scraper, exporting pages with export.Default.
+func NewScraperWiki(name string, scraper scrape.Scraper) Wiki {
+	return &ScraperWiki{MediaWiki{
+		Name:     name,
+		Scraper:  scraper,
+		Exporter: export.Default,
+	}}
+}
+
+// ScrapeManifest scrapes and exports every page in the manifest. Scrapers
+// which implement scrape.BatchScraper are always used in batches, whatever
+// the strategy, as each request to them may be a full pass over a local file.
+func (wiki *ScraperWiki) ScrapeManifest(man util.Manifest, filter *scrape.SectionFilter, _ FetchStrategy) error {
+	return wiki.MediaWiki.ScrapeManifest(man, filter, FetchBatch)
+}
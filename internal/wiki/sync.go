@@ -0,0 +1,166 @@
+package wiki
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/logging"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// ChangeLogName is the name of the file in an export directory that Sync
+// appends its changes to, one JSON object per line.
+const ChangeLogName = "changes.jsonl"
+
+// ChangeKind describes how a page changed since it was exported.
+type ChangeKind string
+
+const (
+	PageUpdated ChangeKind = "updated"
+	PageMoved   ChangeKind = "moved"
+	PageDeleted ChangeKind = "deleted"
+)
+
+// Change is a single entry of the change log written by Sync. NewTitle is
+// only set for moved pages, and ToRevision is zero for deleted pages.
+type Change struct {
+	Time         string     `json:"time"`
+	Kind         ChangeKind `json:"change"`
+	Title        string     `json:"title"`
+	NewTitle     string     `json:"newTitle,omitempty"`
+	FromRevision int        `json:"fromRevision"`
+	ToRevision   int        `json:"toRevision,omitempty"`
+}
+
+// Sync brings a directory of pages exported by export.DirExporter up to
+// date with the wiki. The latest revision of every page is looked up in
+// batches, and only pages edited since they were exported are scraped
+// again, keeping only the sections selected by the filter they were
+// exported with. Moved pages are scraped under their new title, replacing
+// the file of the old one, and the files of deleted pages are removed. When the
+// scraper can request revisions by id, the revision looked up is scraped,
+// so that a cached copy of an older revision is never exported. The changes
+// made are returned and appended to the directory's change log. Pages that
+// fail to scrape, or whose scraped revision is not the one looked up, are
+// logged and left as they are.
+//
+// Can error when:
+//   - The directory cannot be read or written
+//   - The wiki's scraper cannot look up revisions in batches
+//   - A revision lookup fails
+func Sync(wiki Wiki, dir string) ([]*Change, error) {
+	checker, ok := wiki.GetScraper().(scrape.RevisionChecker)
+	if !ok {
+		return nil, &util.WikiNotSupportedError{
+			Code: "syncnotsupported",
+			Info: "The wiki's backend cannot look up the revisions of pages in batches",
+		}
+	}
+	pages, err := export.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string, len(pages))
+	titles := make([]string, 0, len(pages))
+	for file, page := range pages {
+		files[page.Title] = file
+		titles = append(titles, page.Title)
+	}
+	sort.Strings(titles)
+	resolver, pinned := wiki.GetScraper().(scrape.RevisionResolver)
+	states, err := checker.GetLatestRevisions(titles)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var changes []*Change
+	for _, state := range states {
+		file := files[state.Title]
+		stored := pages[file]
+		change := &Change{Time: now, Title: state.Title, FromRevision: stored.Revision}
+		switch {
+		case state.Missing:
+			if err := os.Remove(filepath.Join(dir, file)); err != nil {
+				return changes, err
+			}
+			change.Kind = PageDeleted
+			changes = append(changes, change)
+			continue
+		case state.CurrentTitle != state.Title:
+			change.Kind = PageMoved
+			change.NewTitle = state.CurrentTitle
+		case state.Revision != stored.Revision:
+			change.Kind = PageUpdated
+		default:
+			continue
+		}
+		path := state.CurrentTitle
+		if pinned {
+			path = resolver.RevisionPath(state.Revision)
+		}
+		page, err := scrapeStored(wiki.GetScraper(), path, stored)
+		if err != nil {
+			logging.Log.Warnf("skipping %s: %v", state.Title, err)
+			continue
+		}
+		if page.Revision != state.Revision {
+			logging.Log.Warnf("skipping %s: scraped revision %d, expected %d", state.Title, page.Revision, state.Revision)
+			continue
+		}
+		if err := export.WritePage(dir, page); err != nil {
+			return changes, err
+		}
+		if export.FileName(page.Title) != file {
+			if err := os.Remove(filepath.Join(dir, file)); err != nil {
+				return changes, err
+			}
+		}
+		change.ToRevision = page.Revision
+		changes = append(changes, change)
+	}
+	return changes, appendChangeLog(dir, changes)
+}
+
+// scrapeStored scrapes the page at path with the section filter the stored
+// page was exported with.
+func scrapeStored(scraper scrape.Scraper, path string, stored *export.PageFile) (*scrape.Page, error) {
+	filter, err := stored.Filter.SectionFilter()
+	if err != nil {
+		return nil, err
+	}
+	if filter.IsEmpty() {
+		return scraper.GetPage(path)
+	}
+	return scraper.GetSections(path, filter)
+}
+
+// appendChangeLog appends changes to the change log in dir.
+func appendChangeLog(dir string, changes []*Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	file, err := os.OpenFile(filepath.Join(dir, ChangeLogName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	for _, change := range changes {
+		line, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(file, "%s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
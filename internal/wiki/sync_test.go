@@ -0,0 +1,187 @@
+package wiki_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+)
+
+// testSyncScraper serves the latest revision of each page, by title or by
+// revision path. Redirects maps the old title of a moved page to its new
+// title, and served maps a revision to the revision served in its place,
+// as a stale cache would.
+type testSyncScraper struct {
+	revisions map[string]int
+	redirects map[string]string
+	served    map[int]int
+	fetched   []string
+}
+
+func (s *testSyncScraper) GetPage(path string) (*scrape.Page, error) {
+	title := path
+	var id int
+	if _, err := fmt.Sscanf(path, "revision/%d", &id); err == nil {
+		title = ""
+		for t, revision := range s.revisions {
+			if revision == id {
+				title = t
+			}
+		}
+	}
+	revision, ok := s.revisions[title]
+	if !ok {
+		return nil, fmt.Errorf("missing page %s", path)
+	}
+	if served, ok := s.served[revision]; ok {
+		revision = served
+	}
+	s.fetched = append(s.fetched, path)
+	return &scrape.Page{Title: title, Revision: revision, Sections: []*scrape.Section{
+		{Heading: "Introduction", Index: 0, Content: "Text"},
+		{Heading: "Habitat", Index: 1, Content: "Forests"},
+	}}, nil
+}
+
+func (s *testSyncScraper) RevisionPath(revision int) string {
+	return fmt.Sprintf("revision/%d", revision)
+}
+
+func (s *testSyncScraper) GetRevisionAt(string, time.Time) (int, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
+func (s *testSyncScraper) GetSections(path string, filter *scrape.SectionFilter) (*scrape.Page, error) {
+	page, err := s.GetPage(path)
+	if err != nil {
+		return nil, err
+	}
+	page.Sections, err = filter.Apply(page.Title, page.Sections)
+	page.Filter = filter
+	return page, err
+}
+
+func (s *testSyncScraper) GetLatestRevisions(paths []string) ([]*scrape.PageState, error) {
+	var states []*scrape.PageState
+	for _, path := range paths {
+		current := path
+		if to, ok := s.redirects[path]; ok {
+			current = to
+		}
+		revision, ok := s.revisions[current]
+		states = append(states, &scrape.PageState{Title: path, CurrentTitle: current, Revision: revision, Missing: !ok})
+	}
+	return states, nil
+}
+
+// testSyncWiki provides a testSyncScraper to Sync.
+type testSyncWiki struct {
+	scraper *testSyncScraper
+}
+
+func (w *testSyncWiki) ScrapeManifest(util.Manifest, *scrape.SectionFilter, wiki.FetchStrategy) error {
+	return nil
+}
+func (w *testSyncWiki) ScrapePage(string) error                            { return nil }
+func (w *testSyncWiki) ScrapeSections(string, *scrape.SectionFilter) error { return nil }
+func (w *testSyncWiki) ScrapeSummary(string) error                         { return nil }
+func (w *testSyncWiki) GetScraper() scrape.Scraper                         { return w.scraper }
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	for title, revision := range map[string]int{"Bear": 1, "Wolf": 5, "Old fox": 7, "Lynx": 9} {
+		if err := export.WritePage(dir, &scrape.Page{Title: title, Revision: revision}); err != nil {
+			t.Fatalf("Failed to write page: %v", err)
+		}
+	}
+	scraper := &testSyncScraper{
+		revisions: map[string]int{"Bear": 2, "Wolf": 5, "Red fox": 8},
+		redirects: map[string]string{"Old fox": "Red fox"},
+	}
+
+	changes, err := wiki.Sync(&testSyncWiki{scraper: scraper}, dir)
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	want := []string{"updated Bear 1->2", "deleted Lynx 9->0", "moved Old fox 7->8"}
+	var got []string
+	for _, c := range changes {
+		got = append(got, fmt.Sprintf("%s %s %d->%d", c.Kind, c.Title, c.FromRevision, c.ToRevision))
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Changes mismatch. Got: %v, Want: %v", got, want)
+	}
+	if strings.Join(scraper.fetched, ",") != "revision/2,revision/8" {
+		t.Errorf("Only changed pages should be scraped, got: %v", scraper.fetched)
+	}
+
+	pages, err := export.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	for file, revision := range map[string]int{"Bear.json": 2, "Wolf.json": 5, "Red_fox.json": 8} {
+		if page, ok := pages[file]; !ok || page.Revision != revision {
+			t.Errorf("Expected %s at revision %d, got %+v", file, revision, page)
+		}
+	}
+	if len(pages) != 3 {
+		t.Errorf("Expected deleted and moved pages to be removed, got %d files", len(pages))
+	}
+	log, err := os.ReadFile(filepath.Join(dir, wiki.ChangeLogName))
+	if err != nil || strings.Count(string(log), "\n") != 3 {
+		t.Errorf("Expected 3 change log entries, got %q (%v)", log, err)
+	}
+
+	// A second sync finds nothing to do
+	if changes, err := wiki.Sync(&testSyncWiki{scraper: scraper}, dir); err != nil || len(changes) != 0 {
+		t.Errorf("Expected no changes on second sync, got %d (%v)", len(changes), err)
+	}
+
+	// A page scraped at another revision than the one looked up is skipped
+	scraper.revisions["Bear"] = 3
+	scraper.served = map[int]int{3: 2}
+	if changes, err := wiki.Sync(&testSyncWiki{scraper: scraper}, dir); err != nil || len(changes) != 0 {
+		t.Errorf("Expected a stale page to be skipped, got %d changes (%v)", len(changes), err)
+	}
+	if pages, err := export.ReadDir(dir); err != nil || pages["Bear.json"].Revision != 2 {
+		t.Errorf("Expected Bear.json to be left at revision 2, got %v (%v)", pages["Bear.json"], err)
+	}
+}
+
+func TestSyncFiltered(t *testing.T) {
+	dir := t.TempDir()
+	filter, err := scrape.NewSectionFilter([]string{"habitat"}, nil, nil, []string{"^intro"})
+	if err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+	if err := export.WritePage(dir, &scrape.Page{Title: "Bear", Revision: 1, Filter: filter}); err != nil {
+		t.Fatalf("Failed to write page: %v", err)
+	}
+	scraper := &testSyncScraper{revisions: map[string]int{"Bear": 2}}
+
+	if _, err := wiki.Sync(&testSyncWiki{scraper: scraper}, dir); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	pages, err := export.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	page := pages["Bear.json"]
+	if page == nil || page.Revision != 2 {
+		t.Fatalf("Expected Bear.json at revision 2, got %+v", page)
+	}
+	if len(page.Sections) != 1 || page.Sections[0].Heading != "Habitat" {
+		t.Errorf("Expected only the filtered section to be scraped again, got %+v", page.Sections)
+	}
+	want := &export.PageFilter{Headings: []string{"habitat"}, Exclude: []string{"^intro"}}
+	if page.Filter == nil || fmt.Sprint(*page.Filter) != fmt.Sprint(*want) {
+		t.Errorf("Expected the filter to be kept, got %+v", page.Filter)
+	}
+}
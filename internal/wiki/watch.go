@@ -0,0 +1,321 @@
+package wiki
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/logging"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
+
+// WatchState is the position reached in a wiki's recent changes: the
+// timestamp and id of the last change seen. It is saved after every poll
+// so a restarted watch continues where it left off.
+type WatchState struct {
+	LastSeen string `json:"lastSeen"`
+	LastID   int    `json:"lastId"`
+}
+
+// ReadWatchState reads the state saved at path. A missing file gives an
+// empty state.
+func ReadWatchState(path string) (*WatchState, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &WatchState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state WatchState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// WriteTo saves the state to path.
+func (state *WatchState) WriteTo(path string) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// WatchOptions controls which changes a watch acts on and how often it
+// polls. Changes are listed in Namespaces (the main namespace when empty).
+// When Titles or Categories are given, only pages with one of those titles
+// or belonging to one of those categories are scraped. The state is saved
+// to StatePath after every poll, unless it is empty. OutDir is the directory
+// pages are exported to by export.DirExporter, if any, from which the files
+// of deleted and moved pages are removed.
+type WatchOptions struct {
+	Interval   time.Duration
+	Namespaces []int
+	Titles     []string
+	Categories []string
+	StatePath  string
+	OutDir     string
+}
+
+// watchFilter reports whether changes to a page should be acted on.
+type watchFilter func(title string) bool
+
+// newWatchFilter builds the filter selecting the pages named by opts. Titles
+// are compared in their canonical form, so "dragon_slayer" in a manifest
+// selects changes to "Dragon slayer". The members of categories are listed
+// when the filter is built, so pages added to a category are picked up by
+// the next poll.
+func newWatchFilter(scraper scrape.Scraper, opts *WatchOptions) (watchFilter, error) {
+	if len(opts.Titles) == 0 && len(opts.Categories) == 0 {
+		return func(string) bool { return true }, nil
+	}
+	watched := map[string]bool{}
+	for _, title := range opts.Titles {
+		watched[util.NormalizeTitle(title)] = true
+	}
+	if len(opts.Categories) > 0 {
+		lister, ok := scraper.(scrape.CategoryLister)
+		if !ok {
+			return nil, &util.WikiNotSupportedError{
+				Code: "categoriesnotsupported",
+				Info: "The wiki's backend does not support listing category members",
+			}
+		}
+		for _, category := range opts.Categories {
+			members, err := lister.GetCategoryMembers(category, &scrape.CategoryOptions{Namespaces: opts.Namespaces})
+			if err != nil {
+				return nil, err
+			}
+			for _, title := range members {
+				watched[util.NormalizeTitle(title)] = true
+			}
+		}
+	}
+	return func(title string) bool { return watched[util.NormalizeTitle(title)] }, nil
+}
+
+// Poll lists the changes made to the wiki since the state, then scrapes and
+// exports every watched page that was edited, created or moved, once each.
+// Moved pages are scraped under their new title, and deleted pages are
+// logged. With opts.OutDir, the files of deleted pages are removed, as are
+// those of moved pages once their new title is exported. The latest
+// revision of each page is looked up and scraped when the scraper supports
+// it. Pages that fail to scrape are logged and skipped. The state is
+// advanced past every change listed and saved. Returns the number of pages
+// exported.
+//
+// Can error when:
+//   - The wiki's scraper cannot list recent changes
+//   - Listing changes, category members or latest revisions fails
+//   - The state cannot be saved
+func Poll(wiki Wiki, state *WatchState, opts *WatchOptions) (int, error) {
+	lister, ok := wiki.GetScraper().(scrape.RecentChangesLister)
+	if !ok {
+		return 0, &util.WikiNotSupportedError{
+			Code: "watchnotsupported",
+			Info: "The wiki's backend does not support listing recent changes",
+		}
+	}
+	changesOpts := &scrape.RecentChangesOptions{Namespaces: opts.Namespaces}
+	if state.LastSeen != "" {
+		since, err := time.Parse(time.RFC3339, state.LastSeen)
+		if err != nil {
+			return 0, err
+		}
+		changesOpts.Since = since
+	}
+	changes, err := lister.GetRecentChanges(changesOpts)
+	if err != nil {
+		return 0, err
+	}
+	// Changes made at the last timestamp seen are listed again.
+	var unseen []*scrape.RecentChange
+	for _, change := range changes {
+		if change.ID > state.LastID {
+			unseen = append(unseen, change)
+		}
+	}
+	if len(unseen) == 0 {
+		return 0, nil
+	}
+	watched, err := newWatchFilter(wiki.GetScraper(), opts)
+	if err != nil {
+		return 0, err
+	}
+
+	// Each page is scraped once, unless its last change deleted or moved it.
+	var titles []string
+	queued := map[string]bool{}
+	pending := map[string]bool{}
+	movedTo := map[string]string{}
+	for _, change := range unseen {
+		title := change.Title
+		switch change.Type {
+		case "delete":
+			if watched(title) {
+				logging.Log.Infof("%s was deleted", title)
+				pending[title] = false
+				delete(movedTo, title)
+			}
+			continue
+		case "move":
+			if !watched(title) && !watched(change.NewTitle) {
+				continue
+			}
+			logging.Log.Infof("%s was moved to %s", title, change.NewTitle)
+			pending[title] = false
+			movedTo[title] = change.NewTitle
+			title = change.NewTitle
+		default:
+			if !watched(title) {
+				continue
+			}
+		}
+		if !queued[title] {
+			queued[title] = true
+			titles = append(titles, title)
+		}
+		pending[title] = true
+	}
+
+	var scraped []string
+	for _, title := range titles {
+		if pending[title] {
+			scraped = append(scraped, title)
+		}
+	}
+	paths, err := latestPaths(wiki.GetScraper(), scraped)
+	if err != nil {
+		return 0, err
+	}
+	exported := 0
+	exportedTitles := map[string]bool{}
+	for i, title := range scraped {
+		if paths[i] == "" {
+			logging.Log.Warnf("skipping %s: the page no longer exists", title)
+			continue
+		}
+		if err := wiki.ScrapePage(paths[i]); err != nil {
+			logging.Log.Warnf("skipping %s: %v", title, err)
+			continue
+		}
+		exported++
+		exportedTitles[title] = true
+	}
+	if opts.OutDir != "" {
+		removeStale(opts.OutDir, pending, movedTo, exportedTitles)
+	}
+
+	last := unseen[len(unseen)-1]
+	state.LastSeen = last.Timestamp
+	for _, change := range unseen {
+		state.LastID = max(state.LastID, change.ID)
+	}
+	if opts.StatePath != "" {
+		if err := state.WriteTo(opts.StatePath); err != nil {
+			return exported, err
+		}
+	}
+	return exported, nil
+}
+
+// removeStale removes the files of pages which were deleted, or moved to a
+// title that was exported or deleted since, from dir. Pending holds the
+// titles listed by a poll, false for those deleted or moved away, and
+// movedTo the title each moved page was last moved to. Failures are logged.
+func removeStale(dir string, pending map[string]bool, movedTo map[string]string, exported map[string]bool) {
+	for title, isPending := range pending {
+		if isPending {
+			continue
+		}
+		// Follow the page through its moves to the title it ends up at.
+		final := title
+		for steps := 0; !pending[final] && steps <= len(movedTo); steps++ {
+			next, ok := movedTo[final]
+			if !ok {
+				break
+			}
+			final = next
+		}
+		if pending[final] && !exported[final] {
+			continue
+		}
+		err := os.Remove(filepath.Join(dir, export.FileName(title)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logging.Log.Warnf("removing %s: %v", title, err)
+		}
+	}
+}
+
+// latestPaths returns the paths to scrape for the latest revision of each
+// title. When the scraper can look up and request revisions by id, each
+// path names the latest revision, so that a cached copy of an older
+// revision is never exported, and pages which no longer exist give an
+// empty path. Otherwise the titles themselves are scraped.
+func latestPaths(scraper scrape.Scraper, titles []string) ([]string, error) {
+	checker, canCheck := scraper.(scrape.RevisionChecker)
+	resolver, canResolve := scraper.(scrape.RevisionResolver)
+	if !canCheck || !canResolve || len(titles) == 0 {
+		return titles, nil
+	}
+	states, err := checker.GetLatestRevisions(titles)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(states))
+	for i, state := range states {
+		if !state.Missing {
+			paths[i] = resolver.RevisionPath(state.Revision)
+		}
+	}
+	return paths, nil
+}
+
+// Watch polls the wiki every opts.Interval until ctx is cancelled,
+// re-scraping the pages changed since the previous poll. The state saved at
+// opts.StatePath is resumed; without one the watch starts from the current
+// time. Failed polls are logged and retried at the next interval.
+//
+// Can error when:
+//   - The saved state cannot be read
+//   - The wiki's scraper cannot list recent changes
+func Watch(ctx context.Context, wiki Wiki, opts *WatchOptions) error {
+	if _, ok := wiki.GetScraper().(scrape.RecentChangesLister); !ok {
+		return &util.WikiNotSupportedError{
+			Code: "watchnotsupported",
+			Info: "The wiki's backend does not support listing recent changes",
+		}
+	}
+	state := &WatchState{}
+	if opts.StatePath != "" {
+		var err error
+		if state, err = ReadWatchState(opts.StatePath); err != nil {
+			return err
+		}
+	}
+	if state.LastSeen == "" {
+		state.LastSeen = time.Now().UTC().Format(time.RFC3339)
+	}
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		exported, err := Poll(wiki, state, opts)
+		if err != nil {
+			logging.Log.Errorf("polling recent changes: %v", err)
+		} else if exported > 0 {
+			logging.Log.Infof("exported %d changed pages", exported)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
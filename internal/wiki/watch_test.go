@@ -0,0 +1,224 @@
+package wiki_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+	"github.com/mal0ner/wikiscrape/internal/wiki"
+)
+
+// testRecorder records the titles and revisions of exported pages.
+type testRecorder struct {
+	titles    []string
+	revisions []int
+}
+
+func (r *testRecorder) Export(page *scrape.Page) {
+	r.titles = append(r.titles, page.Title)
+	r.revisions = append(r.revisions, page.Revision)
+}
+
+// testChange is an entry of a fake recent changes feed.
+type testChange struct {
+	timestamp string
+	json      string
+}
+
+// testRecentChanges is a fake MediaWiki API with a recent changes feed.
+// Revisions holds the latest revision of every existing page, and each
+// revision is rendered as a single paragraph.
+type testRecentChanges struct {
+	t         *testing.T
+	changes   []testChange
+	revisions map[string]int
+}
+
+func (rc *testRecentChanges) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case query.Get("list") == "recentchanges":
+		if query.Get("rcdir") != "newer" || query.Get("rcnamespace") != "0" {
+			rc.t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		var listed []string
+		for _, c := range rc.changes {
+			// rcstart is inclusive, so changes made at the start time are listed again.
+			if c.timestamp >= query.Get("rcstart") {
+				listed = append(listed, c.json)
+			}
+		}
+		fmt.Fprintf(w, `{"query":{"recentchanges":[%s]}}`, strings.Join(listed, ","))
+	case query.Get("generator") == "categorymembers":
+		fmt.Fprint(w, `{"query":{"pages":[{"title":"Wolf","ns":0},{"title":"Red fox","ns":0}]}}`)
+	case query.Get("prop") == "revisions":
+		var pages []string
+		for _, title := range strings.Split(query.Get("titles"), "|") {
+			if revision, ok := rc.revisions[title]; ok {
+				pages = append(pages, fmt.Sprintf(`{"title":%q,"revisions":[{"revid":%d}]}`, title, revision))
+			} else {
+				pages = append(pages, fmt.Sprintf(`{"title":%q,"missing":true}`, title))
+			}
+		}
+		fmt.Fprintf(w, `{"query":{"pages":[%s]}}`, strings.Join(pages, ","))
+	case query.Get("action") == "parse" && query.Has("oldid"):
+		for title, revision := range rc.revisions {
+			if fmt.Sprint(revision) == query.Get("oldid") {
+				fmt.Fprintf(w, `{"parse":{"title":%q,"revid":%d,"text":{"*":"<p>Text.</p>"}}}`, title, revision)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"error":{"code":"nosuchrevid","info":"There is no revision with that ID."}}`)
+	default:
+		rc.t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+	}
+}
+
+// newTestRecentChangesServer serves a fake MediaWiki API with a fixed
+// recent changes feed.
+func newTestRecentChangesServer(t *testing.T) (*httptest.Server, *testRecentChanges) {
+	rc := &testRecentChanges{
+		t: t,
+		changes: []testChange{
+			{"2024-01-01T00:00:00Z", `{"type":"edit","title":"Bear","rcid":100,"revid":1000,"timestamp":"2024-01-01T00:00:00Z"}`},
+			{"2024-01-01T01:00:00Z", `{"type":"edit","title":"Bear","rcid":101,"revid":1001,"timestamp":"2024-01-01T01:00:00Z"}`},
+			{"2024-01-01T02:00:00Z", `{"type":"new","title":"Wolf","rcid":102,"revid":1002,"timestamp":"2024-01-01T02:00:00Z"}`},
+			{"2024-01-01T03:00:00Z", `{"type":"log","title":"Old fox","rcid":103,"timestamp":"2024-01-01T03:00:00Z","logtype":"move","logaction":"move","logparams":{"target_title":"Red fox"}}`},
+			{"2024-01-01T04:00:00Z", `{"type":"log","title":"Lynx","rcid":104,"timestamp":"2024-01-01T04:00:00Z","logtype":"delete","logaction":"delete"}`},
+			{"2024-01-01T04:00:00Z", `{"type":"log","title":"User:Alice","rcid":105,"timestamp":"2024-01-01T04:00:00Z","logtype":"block","logaction":"block"}`},
+		},
+		revisions: map[string]int{"Bear": 1001, "Wolf": 1002, "Red fox": 990},
+	}
+	return httptest.NewServer(rc), rc
+}
+
+func TestPoll(t *testing.T) {
+	server, _ := newTestRecentChangesServer(t)
+	defer server.Close()
+	recorder := &testRecorder{}
+	defaultExporter := export.Default
+	export.Default = recorder
+	defer func() { export.Default = defaultExporter }()
+	w := wiki.NewMediaWiki("test", server.URL)
+
+	// Test 1: Every change, each page exported once
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	state := &wiki.WatchState{}
+	exported, err := wiki.Poll(w, state, &wiki.WatchOptions{StatePath: statePath})
+	if err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if got := strings.Join(recorder.titles, ","); exported != 3 || got != "Bear,Wolf,Red fox" {
+		t.Errorf("Exported pages mismatch. Got: %d %s, Want: 3 Bear,Wolf,Red fox", exported, got)
+	}
+
+	// Test 2: A restart resumes from the saved state without repeating changes
+	state, err = wiki.ReadWatchState(statePath)
+	if err != nil {
+		t.Fatalf("Failed to read state: %v", err)
+	}
+	if state.LastSeen != "2024-01-01T04:00:00Z" || state.LastID != 104 {
+		t.Errorf("State mismatch. Got: %+v", state)
+	}
+	recorder.titles = nil
+	if exported, err := wiki.Poll(w, state, &wiki.WatchOptions{StatePath: statePath}); err != nil || exported != 0 {
+		t.Errorf("Expected nothing to export after resuming, got %d (%v) %v", exported, err, recorder.titles)
+	}
+
+	// Test 3: Filtered by a category and a manifest title
+	recorder.titles = nil
+	opts := &wiki.WatchOptions{Titles: []string{"Bear"}, Categories: []string{"Canines"}}
+	if _, err := wiki.Poll(w, &wiki.WatchState{}, opts); err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if got := strings.Join(recorder.titles, ","); got != "Bear,Wolf,Red fox" {
+		t.Errorf("Filtered pages mismatch. Got: %s", got)
+	}
+	recorder.titles = nil
+	opts = &wiki.WatchOptions{Titles: []string{"Wolf"}}
+	if _, err := wiki.Poll(w, &wiki.WatchState{}, opts); err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if got := strings.Join(recorder.titles, ","); got != "Wolf" {
+		t.Errorf("Filtered pages mismatch. Got: %s", got)
+	}
+
+	// Test 4: Manifest titles match in their canonical form
+	recorder.titles = nil
+	opts = &wiki.WatchOptions{Titles: []string{"old_fox", "bear"}}
+	if _, err := wiki.Poll(w, &wiki.WatchState{}, opts); err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if got := strings.Join(recorder.titles, ","); got != "Bear,Red fox" {
+		t.Errorf("Filtered pages mismatch. Got: %s", got)
+	}
+}
+
+func TestPollOutDir(t *testing.T) {
+	server, _ := newTestRecentChangesServer(t)
+	defer server.Close()
+	dir := t.TempDir()
+	for _, title := range []string{"Bear", "Old fox", "Lynx", "Elk"} {
+		if err := export.WritePage(dir, &scrape.Page{Title: title, Revision: 1}); err != nil {
+			t.Fatalf("Failed to write page: %v", err)
+		}
+	}
+	defaultExporter := export.Default
+	export.Default = &export.DirExporter{Dir: dir}
+	defer func() { export.Default = defaultExporter }()
+	w := wiki.NewMediaWiki("test", server.URL)
+
+	if _, err := wiki.Poll(w, &wiki.WatchState{}, &wiki.WatchOptions{OutDir: dir}); err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	pages, err := export.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	var files []string
+	for file := range pages {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	if got, want := strings.Join(files, ","), "Bear.json,Elk.json,Red_fox.json,Wolf.json"; got != want {
+		t.Errorf("Files mismatch. Got: %s, Want: %s", got, want)
+	}
+}
+
+func TestPollCache(t *testing.T) {
+	server, rc := newTestRecentChangesServer(t)
+	defer server.Close()
+	recorder := &testRecorder{}
+	defaultExporter, defaultCache := export.Default, cache.Default
+	export.Default, cache.Default = recorder, cache.New(t.TempDir(), time.Hour)
+	defer func() { export.Default, cache.Default = defaultExporter, defaultCache }()
+	w := wiki.NewMediaWiki("test", server.URL)
+
+	// A poll finding nothing new, which must not be cached for the next
+	state := &wiki.WatchState{}
+	for i := 0; i < 2; i++ {
+		if _, err := wiki.Poll(w, state, &wiki.WatchOptions{Titles: []string{"Bear"}}); err != nil {
+			t.Fatalf("Failed to poll: %v", err)
+		}
+	}
+
+	// Test 1: New changes are listed, and the new revision scraped, despite the cache
+	rc.changes = append(rc.changes, testChange{"2024-01-01T05:00:00Z", `{"type":"edit","title":"Bear","rcid":106,"revid":1003,"timestamp":"2024-01-01T05:00:00Z"}`})
+	rc.revisions["Bear"] = 1003
+	recorder.titles, recorder.revisions = nil, nil
+	exported, err := wiki.Poll(w, state, &wiki.WatchOptions{Titles: []string{"Bear"}})
+	if err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if exported != 1 || len(recorder.revisions) != 1 || recorder.revisions[0] != 1003 {
+		t.Errorf("Expected Bear at revision 1003, got %d pages at revisions %v", exported, recorder.revisions)
+	}
+}
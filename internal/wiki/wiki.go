@@ -1,9 +1,108 @@
 package wiki
 
-import "github.com/mal0ner/wikiscrape/internal/util"
+import (
+	"fmt"
+
+	"github.com/mal0ner/wikiscrape/internal/export"
+	"github.com/mal0ner/wikiscrape/internal/scrape"
+	"github.com/mal0ner/wikiscrape/internal/scrape/cache"
+	"github.com/mal0ner/wikiscrape/internal/util"
+)
 
 type Wiki interface {
-	ScrapeManifest(util.Manifest) error
+	ScrapeManifest(util.Manifest, *scrape.SectionFilter, FetchStrategy) error
 	ScrapePage(string) error
-	ScrapeSection(string, string) error
+	ScrapeSections(string, *scrape.SectionFilter) error
+	ScrapeSummary(string) error
+	GetScraper() scrape.Scraper
+}
+
+// FetchStrategy selects how ScrapeManifest retrieves the pages of a manifest.
+type FetchStrategy string
+
+const (
+	// FetchParse retrieves and parses the rendered HTML of each page individually.
+	FetchParse FetchStrategy = "parse"
+	// FetchBatch retrieves the source of many pages per request, when the
+	// wiki's scraper implements scrape.BatchScraper.
+	FetchBatch FetchStrategy = "batch"
+)
+
+// ParseFetchStrategy validates a fetch strategy name given on the command line.
+func ParseFetchStrategy(name string) (FetchStrategy, error) {
+	switch strategy := FetchStrategy(util.TrimLower(name)); strategy {
+	case FetchParse, FetchBatch:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown fetch strategy %q, expected %s or %s", name, FetchParse, FetchBatch)
+}
+
+// FromQueryData identifies and returns the appropriate wiki for the wiki provider listed
+// in the generated queryData from a command request. Returns a WikiNotSupportedError in the case
+// that the provider is not explicitly supported.
+func FromQueryData(queryData *util.QueryData) (Wiki, error) {
+	backend := util.TrimLower(queryData.Info.Backend)
+	switch backend {
+	case "mediawiki":
+		mediaWiki := NewMediaWiki(backend, queryData.Info.APIPath)
+		return mediaWiki, nil
+	case "fandom":
+		return NewFandom(queryData.Info.Name, queryData.Info.APIPath), nil
+	case "dokuwiki":
+		dokuWiki := &scrape.DokuWikiScraper{BaseURL: queryData.Info.APIPath, Cache: cache.Default}
+		return NewScraperWiki(queryData.Info.Name, dokuWiki), nil
+	case "wikijs":
+		wikiJS := &scrape.WikiJSScraper{
+			BaseURL: queryData.Info.APIPath,
+			Token:   queryData.Info.Token,
+			Locale:  queryData.Info.Locale,
+			Cache:   cache.Default,
+		}
+		return NewScraperWiki(queryData.Info.Name, wikiJS), nil
+	case "confluence":
+		confluence := &scrape.ConfluenceScraper{
+			BaseURL: queryData.Info.APIPath,
+			User:    queryData.Info.User,
+			Token:   queryData.Info.Token,
+			Cache:   cache.Default,
+		}
+		return NewScraperWiki(queryData.Info.Name, confluence), nil
+	case "xmldump":
+		dump := &scrape.XMLDumpScraper{Path: queryData.Info.APIPath}
+		return NewScraperWiki(queryData.Info.Name, dump), nil
+	case "mediawikirest":
+		rest := &scrape.RESTScraper{BaseURL: queryData.Info.APIPath, Cache: cache.Default}
+		return NewScraperWiki(queryData.Info.Name, rest), nil
+	case "gollum":
+		gitWiki := &scrape.GitWikiScraper{Path: queryData.Info.APIPath}
+		return NewScraperWiki(queryData.Info.Name, gitWiki), nil
+	case "tiddlywiki":
+		tiddlyWiki := &scrape.TiddlyWikiScraper{Path: queryData.Info.APIPath, Cache: cache.Default}
+		return NewScraperWiki(queryData.Info.Name, tiddlyWiki), nil
+	case "zim":
+		archive := &scrape.ZIMScraper{Path: queryData.Info.APIPath}
+		return NewScraperWiki(queryData.Info.Name, archive), nil
+	}
+	return nil, &util.WikiNotSupportedError{
+		Code: "backendnotsupported",
+		Info: fmt.Sprintf("The detected backend %s is not yet a supported wiki provider", backend),
+	}
+}
+
+// scrapeSummary fetches and exports the summary of a single page given its
+// name, when the scraper implements scrape.Summarizer.
+func scrapeSummary(scraper scrape.Scraper, exporter export.Exporter, path string) error {
+	summarizer, ok := scraper.(scrape.Summarizer)
+	if !ok {
+		return &util.WikiNotSupportedError{
+			Code: "summarynotsupported",
+			Info: "The wiki's backend does not support page summaries",
+		}
+	}
+	page, err := summarizer.GetSummary(path)
+	if err != nil {
+		return err
+	}
+	exporter.Export(page)
+	return nil
 }